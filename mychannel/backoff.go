@@ -0,0 +1,37 @@
+package mychannel
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoff computes delays which grow by the multiplier up to the max, with an
+// optional random jitter added on top.
+type backoff struct {
+	current    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// next returns the next delay.
+func (b *backoff) next() time.Duration {
+	d := b.current
+	if b.multiplier > 1 {
+		grown := time.Duration(float64(b.current) * b.multiplier)
+		if b.max > 0 && grown > b.max {
+			grown = b.max
+		}
+		b.current = grown
+	}
+	return addJitter(d, b.jitter)
+}
+
+// addJitter adds a random duration of up to jitter*d to d. Delays only get
+// longer, never shorter.
+func addJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || d <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Float64()*jitter*float64(d))
+}
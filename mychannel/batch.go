@@ -0,0 +1,53 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+// Batch collects values into slices. A slice is sent when it reaches maxSize
+// values or when maxWait has elapsed since its first value, whichever comes
+// first. When the input channel is closed, the partial slice is sent before
+// the output channel is closed.
+func Batch[T any](ctx context.Context, in <-chan T, maxSize int, maxWait time.Duration) <-chan []T {
+	if maxSize <= 0 {
+		panic("max size must be positive")
+	}
+	ret := make(chan []T)
+
+	go func() {
+		defer close(ret)
+		for {
+			first, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			values := make([]T, 0, maxSize)
+			values = append(values, first)
+			timer := time.NewTimer(maxWait)
+			closed := false
+		loop:
+			for len(values) < maxSize {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+					break loop
+				case v, ok := <-in:
+					if !ok {
+						closed = true
+						break loop
+					}
+					values = append(values, v)
+				}
+			}
+			timer.Stop()
+			if !WriteOne(ctx, ret, values) || closed {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
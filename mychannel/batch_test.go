@@ -0,0 +1,66 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < 7; i++ {
+			in <- i
+		}
+		time.Sleep(50 * time.Millisecond)
+		in <- 7
+		in <- 8
+		time.Sleep(50 * time.Millisecond)
+		in <- 9
+	}()
+
+	out := Batch(ctx, in, 3, 20*time.Millisecond)
+
+	var sizes []int
+	total := 0
+	for values := range out {
+		sizes = append(sizes, len(values))
+		for _, v := range values {
+			if v != total {
+				t.Errorf("expected %d, got %d", total, v)
+			}
+			total++
+		}
+	}
+	expected := []int{3, 3, 1, 2, 1}
+	if len(sizes) != len(expected) {
+		t.Fatalf("expected batch sizes %v, got %v", expected, sizes)
+	}
+	for i := range expected {
+		if sizes[i] != expected[i] {
+			t.Errorf("expected batch sizes %v, got %v", expected, sizes)
+			break
+		}
+	}
+}
+
+func TestBatchFlushesOnClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int, 2)
+	in <- 1
+	in <- 2
+	close(in)
+
+	out := Batch(ctx, in, 10, time.Hour)
+	values := <-out
+	if len(values) != 2 {
+		t.Errorf("expected 2 elements, got %d", len(values))
+	}
+	if _, ok := <-out; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
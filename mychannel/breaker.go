@@ -0,0 +1,342 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Breaker.Handle for the values which were
+// shed because the breaker is open.
+var ErrBreakerOpen = errors.New("breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed passes all values to the consumer.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen sheds or buffers all values until the cooldown passes.
+	BreakerOpen
+	// BreakerHalfOpen lets a single value through to check whether the
+	// consumer recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// breakerChangesSize is the buffer size of the StateChanges channel.
+const breakerChangesSize = 16
+
+// BreakerConfig configures a Breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures which open the
+	// breaker.
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before it lets a value
+	// through again.
+	Cooldown time.Duration
+	// BufferSize is the number of values kept while the breaker is open.
+	// They are passed to the consumer once it recovers. The values over the
+	// limit are shed, 0 sheds all of them.
+	BufferSize int
+}
+
+// Breaker is a circuit breaker around a consumer function. After
+// FailureThreshold consecutive failures it opens and stops calling the
+// consumer. After the cooldown it half-opens and lets a single value through,
+// which either closes it again or keeps it open for another cooldown.
+type Breaker[T any] struct {
+	mu  *sync.Mutex
+	fn  func(context.Context, T) error
+	cfg BreakerConfig
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// probing is true while the single half-open value is being handled.
+	probing  bool
+	flushing bool
+	buf      ring[T]
+	changes  chan BreakerState
+}
+
+// NewBreaker creates a new closed Breaker around fn.
+func NewBreaker[T any](fn func(context.Context, T) error, cfg BreakerConfig) *Breaker[T] {
+	return &Breaker[T]{
+		mu:      &sync.Mutex{},
+		fn:      fn,
+		cfg:     cfg,
+		changes: make(chan BreakerState, breakerChangesSize),
+	}
+}
+
+// State returns the current state.
+func (b *Breaker[T]) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// StateChanges returns a channel which receives every new state. It is
+// buffered, and when the buffer is full the oldest change is dropped, so a
+// reader which falls behind still gets the latest state last.
+func (b *Breaker[T]) StateChanges() <-chan BreakerState {
+	return b.changes
+}
+
+// Pending returns the number of buffered values which were not passed to the
+// consumer yet.
+func (b *Breaker[T]) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.len()
+}
+
+// Handle passes the value to the consumer and returns its error. While the
+// breaker is open, the value is buffered and nil is returned, or if the
+// buffer is full, it's shed and ErrBreakerOpen is returned. While there are
+// buffered values, new values are buffered behind them too, so the consumer
+// gets them in order.
+func (b *Breaker[T]) Handle(ctx context.Context, v T) error {
+	b.mu.Lock()
+	if b.buf.len() > 0 {
+		var err error
+		if b.buf.len() < b.cfg.BufferSize {
+			b.buf.push(v)
+		} else {
+			err = ErrBreakerOpen
+		}
+		b.mu.Unlock()
+		// delivers the oldest values first if the breaker lets them
+		// through
+		b.probeBuffered(ctx)
+		return err
+	}
+	ok, probe := b.allow()
+	if !ok {
+		defer b.mu.Unlock()
+		if b.buf.len() < b.cfg.BufferSize {
+			b.buf.push(v)
+			return nil
+		}
+		return ErrBreakerOpen
+	}
+	b.mu.Unlock()
+
+	err := b.fn(ctx, v)
+	b.done(err, probe)
+	if err == nil {
+		b.flush(ctx)
+	}
+	return err
+}
+
+// Run handles the values from the channel until it's closed or the context
+// is cancelled, in which case the context's error is returned. Unlike
+// Handle, it also retries the buffered values when the cooldown passes
+// without new values coming in. The consumer's errors only count towards
+// opening the breaker.
+// Once the channel is closed, Run keeps retrying the buffered values until
+// all of them are delivered. If the context is done first, its error is
+// returned and Pending reports the values which were not delivered.
+func (b *Breaker[T]) Run(ctx context.Context, in <-chan T) error {
+	for {
+		d, pending := b.retryIn()
+		if in == nil && !pending {
+			return nil
+		}
+		var timer *time.Timer
+		var retry <-chan time.Time
+		if pending {
+			timer = time.NewTimer(d)
+			retry = timer.C
+		}
+		select {
+		case v, ok := <-in:
+			if !ok {
+				// stop reading, but flush the buffer
+				in = nil
+				break
+			}
+			_ = b.Handle(ctx, v)
+		case <-retry:
+			b.probeBuffered(ctx)
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// allow decides whether a value can be passed to the consumer and whether
+// it's the half-open probe. Must be called with the lock held.
+func (b *Breaker[T]) allow() (ok bool, probe bool) {
+	switch b.state {
+	case BreakerClosed:
+		return true, false
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.Cooldown {
+			return false, false
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.probing {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// done records the consumer's result.
+func (b *Breaker[T]) done(err error, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if err == nil {
+		b.failures = 0
+		if probe {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if probe || (b.state == BreakerClosed && b.failures >= b.cfg.FailureThreshold) {
+		b.open()
+	}
+}
+
+// open opens the breaker. Must be called with the lock held.
+func (b *Breaker[T]) open() {
+	b.openedAt = time.Now()
+	b.setState(BreakerOpen)
+}
+
+// setState must be called with the lock held.
+func (b *Breaker[T]) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	for {
+		select {
+		case b.changes <- s:
+			return
+		default:
+		}
+		// make room by dropping the oldest change, like OverflowDropOldest
+		select {
+		case <-b.changes:
+		default:
+		}
+	}
+}
+
+// retryIn returns how long until the buffered values can be retried, or false
+// if there are none.
+func (b *Breaker[T]) retryIn() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.buf.len() == 0 {
+		return 0, false
+	}
+	switch b.state {
+	case BreakerClosed:
+		// a failed flush which didn't open the breaker
+		return 0, true
+	case BreakerOpen:
+		return max(b.cfg.Cooldown-time.Since(b.openedAt), 0), true
+	}
+	// another caller is probing
+	return b.cfg.Cooldown, true
+}
+
+// probeBuffered uses the oldest buffered value as the half-open probe, or
+// flushes the buffer if the breaker is closed.
+func (b *Breaker[T]) probeBuffered(ctx context.Context) {
+	b.mu.Lock()
+	if b.state == BreakerClosed {
+		b.mu.Unlock()
+		b.flush(ctx)
+		return
+	}
+	ok, probe := b.allow()
+	if !ok {
+		b.mu.Unlock()
+		return
+	}
+	v, ok := b.buf.peek()
+	if !ok {
+		if probe {
+			b.probing = false
+		}
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+
+	err := b.fn(ctx, v)
+	if err == nil {
+		b.mu.Lock()
+		b.buf.pop()
+		b.mu.Unlock()
+	}
+	b.done(err, probe)
+	if err == nil {
+		b.flush(ctx)
+	}
+}
+
+// flush passes the buffered values to the consumer while the breaker is
+// closed. A failed value stays in the buffer.
+func (b *Breaker[T]) flush(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.flushing {
+		return
+	}
+	b.flushing = true
+	defer func() { b.flushing = false }()
+
+	for b.state == BreakerClosed {
+		v, ok := b.buf.peek()
+		if !ok {
+			return
+		}
+		b.mu.Unlock()
+		err := b.fn(ctx, v)
+		b.mu.Lock()
+		if err != nil {
+			b.failures++
+			if b.failures >= b.cfg.FailureThreshold {
+				b.open()
+			}
+			return
+		}
+		b.failures = 0
+		b.buf.pop()
+	}
+}
@@ -0,0 +1,246 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errDownstream = errors.New("downstream is down")
+
+// flakyConsumer fails while broken is set and records the handled values.
+type flakyConsumer struct {
+	mu      sync.Mutex
+	broken  atomic.Bool
+	calls   atomic.Int32
+	handled []int
+}
+
+func (c *flakyConsumer) handle(_ context.Context, v int) error {
+	c.calls.Add(1)
+	if c.broken.Load() {
+		return errDownstream
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handled = append(c.handled, v)
+	return nil
+}
+
+func (c *flakyConsumer) values() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.handled)
+}
+
+func TestBreakerShed(t *testing.T) {
+	ctx := context.Background()
+	c := &flakyConsumer{}
+	c.broken.Store(true)
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 2, Cooldown: 20 * time.Millisecond})
+
+	if err := b.Handle(ctx, 1); !errors.Is(err, errDownstream) {
+		t.Errorf("expected %v, got %v", errDownstream, err)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Errorf("expected %s, got %s", BreakerClosed, s)
+	}
+	b.Handle(ctx, 2)
+	if s := b.State(); s != BreakerOpen {
+		t.Errorf("expected %s, got %s", BreakerOpen, s)
+	}
+	if err := b.Handle(ctx, 3); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("expected %v, got %v", ErrBreakerOpen, err)
+	}
+	if n := c.calls.Load(); n != 2 {
+		t.Errorf("expected the consumer to be called 2 times, got %d", n)
+	}
+
+	// the probe fails and the breaker opens again
+	time.Sleep(25 * time.Millisecond)
+	if err := b.Handle(ctx, 4); !errors.Is(err, errDownstream) {
+		t.Errorf("expected %v, got %v", errDownstream, err)
+	}
+	if s := b.State(); s != BreakerOpen {
+		t.Errorf("expected %s, got %s", BreakerOpen, s)
+	}
+
+	c.broken.Store(false)
+	time.Sleep(25 * time.Millisecond)
+	if err := b.Handle(ctx, 5); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Errorf("expected %s, got %s", BreakerClosed, s)
+	}
+
+	expected := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	for _, exp := range expected {
+		if s := <-b.StateChanges(); s != exp {
+			t.Errorf("expected %s, got %s", exp, s)
+		}
+	}
+}
+
+func TestBreakerBuffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	c := &flakyConsumer{}
+	c.broken.Store(true)
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 1, Cooldown: 20 * time.Millisecond, BufferSize: 2})
+
+	in := make(chan int)
+	errc := make(chan error)
+	go func() {
+		errc <- b.Run(ctx, in)
+	}()
+
+	// 1 opens the breaker, 2 and 3 are buffered and 4 is shed
+	for i := 1; i <= 4; i++ {
+		in <- i
+	}
+	if n := b.Pending(); n != 2 {
+		t.Errorf("expected 2 pending values, got %d", n)
+	}
+
+	// without new values, the buffered ones are retried after the cooldown
+	c.broken.Store(false)
+	waitFor(func() bool { return b.Pending() == 0 })
+	if n := b.Pending(); n != 0 {
+		t.Errorf("expected no pending values, got %d", n)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Errorf("expected %s, got %s", BreakerClosed, s)
+	}
+
+	in <- 5
+	close(in)
+	if err := <-errc; err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if v := c.values(); !slices.Equal(v, []int{2, 3, 5}) {
+		t.Errorf("expected [2 3 5], got %v", v)
+	}
+}
+
+func TestBreakerRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := NewBreaker(func(context.Context, int) error { return nil }, BreakerConfig{FailureThreshold: 1})
+	cancel()
+	if err := b.Run(ctx, make(chan int)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestBreakerRunFlushesOnClose(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	c := &flakyConsumer{}
+	c.broken.Store(true)
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Millisecond, BufferSize: 10})
+
+	// 1 opens the breaker and the rest is buffered
+	in := make(chan int, 4)
+	for i := 1; i <= 4; i++ {
+		in <- i
+	}
+	close(in)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		c.broken.Store(false)
+	}()
+	if err := b.Run(ctx, in); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if n := b.Pending(); n != 0 {
+		t.Errorf("expected no pending values, got %d", n)
+	}
+	if v := c.values(); !slices.Equal(v, []int{2, 3, 4}) {
+		t.Errorf("expected [2 3 4], got %v", v)
+	}
+}
+
+func TestBreakerRunPendingOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := &flakyConsumer{}
+	c.broken.Store(true)
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Millisecond, BufferSize: 10})
+
+	in := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		in <- i
+	}
+	close(in)
+
+	if err := b.Run(ctx, in); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if n := b.Pending(); n != 2 {
+		t.Errorf("expected 2 pending values, got %d", n)
+	}
+}
+
+func TestBreakerKeepsOrderAfterRecovery(t *testing.T) {
+	ctx := context.Background()
+	c := &flakyConsumer{}
+	c.broken.Store(true)
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Millisecond, BufferSize: 10})
+
+	// 1 opens the breaker, 2 and 3 are buffered
+	for i := 1; i <= 3; i++ {
+		b.Handle(ctx, i)
+	}
+	c.broken.Store(false)
+	time.Sleep(15 * time.Millisecond)
+
+	// the buffered values go first
+	if err := b.Handle(ctx, 4); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := b.Handle(ctx, 5); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if v := c.values(); !slices.Equal(v, []int{2, 3, 4, 5}) {
+		t.Errorf("expected [2 3 4 5], got %v", v)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Errorf("expected %s, got %s", BreakerClosed, s)
+	}
+}
+
+func TestBreakerStateChangesKeepLatest(t *testing.T) {
+	ctx := context.Background()
+	c := &flakyConsumer{}
+	b := NewBreaker(c.handle, BreakerConfig{FailureThreshold: 1})
+
+	// nobody reads the changes, flip the state more often than the buffer
+	// can hold
+	for range breakerChangesSize {
+		c.broken.Store(true)
+		b.Handle(ctx, 0)
+		c.broken.Store(false)
+		b.Handle(ctx, 0)
+	}
+	if s := b.State(); s != BreakerClosed {
+		t.Fatalf("expected %s, got %s", BreakerClosed, s)
+	}
+
+	var last BreakerState
+	for range breakerChangesSize {
+		last = <-b.StateChanges()
+	}
+	if last != BreakerClosed {
+		t.Errorf("expected the last change to be %s, got %s", BreakerClosed, last)
+	}
+	select {
+	case s := <-b.StateChanges():
+		t.Errorf("expected no more changes, got %s", s)
+	default:
+	}
+}
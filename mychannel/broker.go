@@ -0,0 +1,156 @@
+package mychannel
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type brokerTopic[T any] struct {
+	in     chan T
+	holder FanOutHolder[T]
+	subs   int
+}
+
+// Broker is a publish/subscribe hub. Publishers send values to a topic and
+// every subscriber whose pattern matches the topic receives them. Each
+// pattern is served by its own FanOutHolder.
+type Broker[K comparable, T any] struct {
+	mu     *sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed bool
+
+	match    func(pattern, topic K) bool
+	patterns map[K]*brokerTopic[T]
+	subs     map[<-chan T]K
+}
+
+// NewBroker creates a new Broker. The match function decides whether a
+// subscription pattern matches a published topic. If it's nil, the pattern
+// must be equal to the topic. See PrefixMatch for wildcard matching of string
+// topics.
+func NewBroker[K comparable, T any](ctx context.Context, match func(pattern, topic K) bool) *Broker[K, T] {
+	ctx, cancel := context.WithCancel(ctx)
+	b := &Broker[K, T]{
+		mu:       &sync.RWMutex{},
+		ctx:      ctx,
+		cancel:   cancel,
+		match:    match,
+		patterns: make(map[K]*brokerTopic[T]),
+		subs:     make(map[<-chan T]K),
+	}
+	go func() {
+		<-ctx.Done()
+		b.Close()
+	}()
+	return b
+}
+
+// PrefixMatch matches string topics. The pattern "*" matches every topic, a
+// pattern ending with "*" matches every topic starting with the rest of the
+// pattern ("orders.*" matches "orders.created") and any other pattern must be
+// equal to the topic.
+func PrefixMatch(pattern, topic string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(topic, prefix)
+	}
+	return pattern == topic
+}
+
+// Subscribe returns a channel with the given buffer size which receives all
+// values published to the topics matching the pattern. A slow subscriber
+// slows down the publishers of the matching topics.
+func (b *Broker[K, T]) Subscribe(pattern K, bufsize int) <-chan T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		panic("Broker already closed")
+	}
+	t, ok := b.patterns[pattern]
+	if !ok {
+		in := make(chan T)
+		t = &brokerTopic[T]{
+			in:     in,
+			holder: FanOut(b.ctx, in),
+		}
+		t.holder.Start()
+		b.patterns[pattern] = t
+	}
+	ch := t.holder.Add(bufsize)
+	t.subs++
+	b.subs[ch] = pattern
+	return ch
+}
+
+// Unsubscribe removes the subscription, closes its channel and discards the
+// values left in it.
+func (b *Broker[K, T]) Unsubscribe(ch <-chan T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	pattern, ok := b.subs[ch]
+	if !ok {
+		return
+	}
+	delete(b.subs, ch)
+	t := b.patterns[pattern]
+	t.holder.Remove(ch)
+	t.subs--
+	if t.subs == 0 {
+		t.holder.Close()
+		delete(b.patterns, pattern)
+	}
+}
+
+// Publish sends the value to all subscribers of the topic. It returns false
+// if the context was cancelled before the value was handed over to all of
+// them.
+func (b *Broker[K, T]) Publish(ctx context.Context, topic K, v T) (ctxAlive bool) {
+	b.mu.RLock()
+	var targets []*brokerTopic[T]
+	if b.match == nil {
+		if t, ok := b.patterns[topic]; ok {
+			targets = append(targets, t)
+		}
+	} else {
+		for pattern, t := range b.patterns {
+			if b.match(pattern, topic) {
+				targets = append(targets, t)
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	// the lock is not held while sending, so a slow subscriber can still
+	// unsubscribe
+	for _, t := range targets {
+		select {
+		case t.in <- v:
+		case <-t.holder.ctx.Done():
+			// unsubscribed or closed in the meantime
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// Close closes all subscriptions.
+func (b *Broker[K, T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.cancel()
+	for _, t := range b.patterns {
+		t.holder.Close()
+	}
+}
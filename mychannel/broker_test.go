@@ -0,0 +1,77 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBroker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	b := NewBroker[string, int](ctx, PrefixMatch)
+	defer b.Close()
+
+	created := b.Subscribe("orders.created", 10)
+	orders := b.Subscribe("orders.*", 10)
+	all := b.Subscribe("*", 10)
+
+	b.Publish(ctx, "orders.created", 1)
+	b.Publish(ctx, "orders.deleted", 2)
+	b.Publish(ctx, "users.created", 3)
+
+	expect := func(name string, ch <-chan int, expected ...int) {
+		t.Helper()
+		for _, exp := range expected {
+			select {
+			case v := <-ch:
+				if v != exp {
+					t.Errorf("%s: expected %d, got %d", name, exp, v)
+				}
+			case <-time.After(100 * time.Millisecond):
+				t.Fatalf("%s: expected %d, got nothing", name, exp)
+			}
+		}
+		select {
+		case v := <-ch:
+			t.Errorf("%s: expected no more values, got %d", name, v)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	expect("created", created, 1)
+	expect("orders", orders, 1, 2)
+	expect("all", all, 1, 2, 3)
+
+	b.Unsubscribe(orders)
+	if _, ok := <-orders; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+	// unsubscribing twice does nothing
+	b.Unsubscribe(orders)
+
+	b.Publish(ctx, "orders.created", 4)
+	expect("created", created, 4)
+	expect("all", all, 4)
+}
+
+func TestBrokerExactMatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	b := NewBroker[int, string](ctx, nil)
+	one := b.Subscribe(1, 1)
+	b.Subscribe(2, 1)
+
+	b.Publish(ctx, 1, "one")
+	// nobody listens to 3
+	b.Publish(ctx, 3, "three")
+
+	if v := <-one; v != "one" {
+		t.Errorf("expected one, got %s", v)
+	}
+
+	cancel()
+	for range one {
+	}
+}
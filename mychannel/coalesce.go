@@ -0,0 +1,164 @@
+package mychannel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type coalesceCall[V any] struct {
+	done    chan struct{}
+	res     Result[V]
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type coalesceCached[V any] struct {
+	res     Result[V]
+	expires time.Time
+}
+
+type coalesceExpiry[K comparable] struct {
+	key     K
+	expires time.Time
+}
+
+// CoalesceHolder makes concurrent callers asking for the same key share a
+// single computation (singleflight).
+type CoalesceHolder[K comparable, V any] struct {
+	mu    *sync.Mutex
+	ttl   time.Duration
+	calls map[K]*coalesceCall[V]
+	cache map[K]coalesceCached[V]
+	// expiries lists the cached keys in the order they expire, which is the
+	// order they were cached in as the ttl is the same for all of them.
+	expiries ring[coalesceExpiry[K]]
+}
+
+// Coalesce creates a new CoalesceHolder. Successful results are cached for
+// the given ttl, use 0 to only share the computations which are in flight.
+func Coalesce[K comparable, V any](ttl time.Duration) *CoalesceHolder[K, V] {
+	return &CoalesceHolder[K, V]{
+		mu:    &sync.Mutex{},
+		ttl:   ttl,
+		calls: make(map[K]*coalesceCall[V]),
+		cache: make(map[K]coalesceCached[V]),
+	}
+}
+
+// Do calls fn for the key unless a call for the same key is already in
+// flight, in which case it waits for that one. The result is sent to the
+// returned channel, which is closed afterwards.
+// If the context is cancelled, the caller gets the context error while the
+// computation continues for the other callers. The computation's own context
+// is cancelled only when all its callers are gone.
+func (h *CoalesceHolder[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) <-chan Result[V] {
+	out := make(chan Result[V], 1)
+
+	h.mu.Lock()
+	if cached, ok := h.cache[key]; ok {
+		if time.Now().Before(cached.expires) {
+			h.mu.Unlock()
+			out <- cached.res
+			close(out)
+			return out
+		}
+		delete(h.cache, key)
+	}
+	c, ok := h.calls[key]
+	if !ok {
+		c = h.start(ctx, key, fn)
+	}
+	c.waiters++
+	h.mu.Unlock()
+
+	go func() {
+		defer close(out)
+		select {
+		case <-c.done:
+			out <- c.res
+		case <-ctx.Done():
+			out <- Fail[V](ctx.Err())
+			h.leave(key, c)
+		}
+	}()
+
+	return out
+}
+
+// start starts the computation. Must be called with the lock held.
+func (h *CoalesceHolder[K, V]) start(ctx context.Context, key K, fn func(context.Context) (V, error)) *coalesceCall[V] {
+	// the computation must outlive the caller which started it, but keep
+	// the context values
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &coalesceCall[V]{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	h.calls[key] = c
+
+	go func() {
+		defer cancel()
+		v, err := fn(cctx)
+		c.res = Result[V]{Value: v, Err: err}
+
+		h.mu.Lock()
+		if h.calls[key] == c {
+			delete(h.calls, key)
+			if err == nil && h.ttl > 0 {
+				h.store(key, c.res)
+			}
+		}
+		h.mu.Unlock()
+		close(c.done)
+	}()
+
+	return c
+}
+
+// store caches the result and removes the expired ones, so that the keys
+// which are not requested again don't pile up. Must be called with the lock
+// held.
+func (h *CoalesceHolder[K, V]) store(key K, res Result[V]) {
+	now := time.Now()
+	for {
+		e, ok := h.expiries.peek()
+		if !ok || now.Before(e.expires) {
+			break
+		}
+		h.expiries.pop()
+		// the key might have been forgotten or cached again since
+		if cached, ok := h.cache[e.key]; ok && cached.expires.Equal(e.expires) {
+			delete(h.cache, e.key)
+		}
+	}
+
+	expires := now.Add(h.ttl)
+	h.cache[key] = coalesceCached[V]{res: res, expires: expires}
+	h.expiries.push(coalesceExpiry[K]{key: key, expires: expires})
+}
+
+// leave cancels the computation once all its callers are gone.
+func (h *CoalesceHolder[K, V]) leave(key K, c *coalesceCall[V]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	c.cancel()
+	// the next caller starts a new computation instead of joining the
+	// cancelled one
+	if h.calls[key] == c {
+		delete(h.calls, key)
+	}
+}
+
+// Forget removes the cached result for the key.
+func (h *CoalesceHolder[K, V]) Forget(key K) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.cache, key)
+}
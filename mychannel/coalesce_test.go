@@ -0,0 +1,144 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	h := Coalesce[string, int](0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var results []<-chan Result[int]
+	for range 10 {
+		results = append(results, h.Do(ctx, "key", fn))
+	}
+	close(release)
+
+	for _, res := range results {
+		v, err := (<-res).Unwrap()
+		if err != nil || v != 42 {
+			t.Errorf("expected 42, got %d %v", v, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 call, got %d", n)
+	}
+
+	// without caching, the next call computes again
+	<-h.Do(ctx, "key", fn)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestCoalesceCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	h := Coalesce[string, int](50 * time.Millisecond)
+	var calls atomic.Int32
+	fn := func(context.Context) (int, error) {
+		return int(calls.Add(1)), nil
+	}
+
+	if res := <-h.Do(ctx, "key", fn); res.Value != 1 {
+		t.Errorf("expected 1, got %d", res.Value)
+	}
+	if res := <-h.Do(ctx, "key", fn); res.Value != 1 {
+		t.Errorf("expected the cached 1, got %d", res.Value)
+	}
+	h.Forget("key")
+	if res := <-h.Do(ctx, "key", fn); res.Value != 2 {
+		t.Errorf("expected 2, got %d", res.Value)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if res := <-h.Do(ctx, "key", fn); res.Value != 3 {
+		t.Errorf("expected 3 after the cache expired, got %d", res.Value)
+	}
+
+	// errors are not cached
+	errBoom := errors.New("boom")
+	failing := func(context.Context) (int, error) {
+		calls.Add(1)
+		return 0, errBoom
+	}
+	<-h.Do(ctx, "err", failing)
+	<-h.Do(ctx, "err", failing)
+	if n := calls.Load(); n != 5 {
+		t.Errorf("expected 5 calls, got %d", n)
+	}
+}
+
+func TestCoalesceCancel(t *testing.T) {
+	h := Coalesce[string, int](0)
+	computationCancelled := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(computationCancelled)
+		return 0, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	res1 := h.Do(ctx1, "key", fn)
+	res2 := h.Do(ctx2, "key", fn)
+
+	cancel1()
+	if res := <-res1; !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, res.Err)
+	}
+	select {
+	case <-computationCancelled:
+		t.Fatal("expected the computation to continue for the other caller")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-res2
+	}()
+	cancel2()
+	wg.Wait()
+	select {
+	case <-computationCancelled:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected the computation to be cancelled")
+	}
+}
+
+func TestCoalesceCacheSweep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	h := Coalesce[int, int](10 * time.Millisecond)
+	fn := func(context.Context) (int, error) { return 1, nil }
+	for i := range 100 {
+		<-h.Do(ctx, i, fn)
+	}
+	time.Sleep(15 * time.Millisecond)
+
+	// caching a new key removes the expired ones
+	<-h.Do(ctx, -1, fn)
+	h.mu.Lock()
+	n := len(h.cache)
+	h.mu.Unlock()
+	if n != 1 {
+		t.Errorf("expected 1 cached key, got %d", n)
+	}
+}
@@ -6,6 +6,12 @@ import (
 )
 
 func DebounceAll[T any](ctx context.Context, in <-chan T, delay time.Duration) <-chan []T {
+	return DebounceAllObserved(ctx, in, delay, NopObserver{})
+}
+
+// DebounceAllObserved is DebounceAll which reports every value read as In and
+// every slice sent as Out to the observer.
+func DebounceAllObserved[T any](ctx context.Context, in <-chan T, delay time.Duration, obs Observer) <-chan []T {
 	ret := make(chan []T)
 
 	go func() {
@@ -15,6 +21,7 @@ func DebounceAll[T any](ctx context.Context, in <-chan T, delay time.Duration) <
 			if !ok || !ctxAlive {
 				return
 			}
+			obs.In()
 			values := []T{first}
 			timer := time.NewTimer(delay)
 			// stopping the timer just in case we exit here because of the context
@@ -27,13 +34,17 @@ func DebounceAll[T any](ctx context.Context, in <-chan T, delay time.Duration) <
 				case <-timer.C:
 					break loop
 				case v := <-in:
+					obs.In()
 					values = append(values, v)
 				}
 			}
 			timer.Stop()
+			start := time.Now()
 			if !WriteOne(ctx, ret, values) {
 				return
 			}
+			obs.Out()
+			obs.Blocked(time.Since(start))
 		}
 	}()
 
@@ -82,3 +93,54 @@ func DebounceLast[T any](ctx context.Context, in <-chan T, delay time.Duration)
 
 	return ret
 }
+
+// DebounceTrailing collects values until there is a pause of the given delay
+// between two values and then sends all of them. Unlike DebounceAll, the
+// timer restarts on every new value. To prevent a busy stream from never
+// being sent, the values are sent anyway once maxWait has elapsed since the
+// first one. If the input channel is closed, the pending values are still
+// sent.
+func DebounceTrailing[T any](ctx context.Context, in <-chan T, delay, maxWait time.Duration) <-chan []T {
+	ret := make(chan []T)
+
+	go func() {
+		defer close(ret)
+		for {
+			first, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			values := []T{first}
+			timer := time.NewTimer(delay)
+			ceiling := time.NewTimer(maxWait)
+			closed := false
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					ceiling.Stop()
+					return
+				case <-timer.C:
+					break loop
+				case <-ceiling.C:
+					break loop
+				case v, ok := <-in:
+					if !ok {
+						closed = true
+						break loop
+					}
+					values = append(values, v)
+					timer.Reset(delay)
+				}
+			}
+			timer.Stop()
+			ceiling.Stop()
+			if !WriteOne(ctx, ret, values) || closed {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
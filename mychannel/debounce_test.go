@@ -102,7 +102,11 @@ func TestDebounceAll(t *testing.T) {
 				}
 				dump(3)
 				time.Sleep(2 * time.Second)
-				dump(5)
+				// the context has expired and no one reads anymore, so
+				// these must not block forever
+				for range 5 {
+					TryWrite(in, 5)
+				}
 			},
 			assert: func(out <-chan []int) {
 				els := <-out
@@ -290,3 +294,42 @@ func TestDebounceLast(t *testing.T) {
 		})
 	}
 }
+
+func TestDebounceTrailing(t *testing.T) {
+	defdur := 20 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		// values keep coming faster than the delay so only the max wait
+		// ceiling flushes them
+		for i := 0; i < 10; i++ {
+			in <- i
+			time.Sleep(defdur / 2)
+		}
+		time.Sleep(2 * defdur)
+		in <- 10
+	}()
+
+	out := DebounceTrailing(ctx, in, defdur, 5*defdur/2)
+
+	var batches [][]int
+	for values := range out {
+		batches = append(batches, values)
+	}
+	if len(batches) < 3 {
+		t.Fatalf("expected the max wait to split the values, got %v", batches)
+	}
+	last := batches[len(batches)-1]
+	if len(last) != 1 || last[0] != 10 {
+		t.Errorf("expected the last batch to be [10], got %v", last)
+	}
+	total := 0
+	for _, b := range batches {
+		total += len(b)
+	}
+	if total != 11 {
+		t.Errorf("expected 11 values, got %d", total)
+	}
+}
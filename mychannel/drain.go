@@ -1,7 +1,37 @@
 package mychannel
 
+import "context"
+
 func Drain[T any](ch chan T) {
 	close(ch)
 	for range ch {
 	}
 }
+
+// DrainContext calls onItem for every value read from the channel until it's
+// closed, so that buffered work can be flushed instead of thrown away. Unlike
+// Drain, it doesn't close the channel, which is left to its owner, so it can
+// be used while senders are still running. It stops when the context is done,
+// leaving the remaining values in the channel, which bounds the wait for
+// senders which never stop. onItem can be nil. It returns the number of values
+// drained.
+func DrainContext[T any](ctx context.Context, ch <-chan T, onItem func(T)) (drained int, ctxAlive bool) {
+	for {
+		// ReadOne picks randomly when both are ready, so the context is
+		// checked first to stop right at the deadline
+		if ctx.Err() != nil {
+			return drained, false
+		}
+		v, ok, alive := ReadOne(ctx, ch)
+		if !alive {
+			return drained, false
+		}
+		if !ok {
+			return drained, true
+		}
+		if onItem != nil {
+			onItem(v)
+		}
+		drained++
+	}
+}
@@ -1,8 +1,10 @@
 package mychannel
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestDraining(t *testing.T) {
@@ -38,3 +40,76 @@ func isChannelClosed[T any](ch chan T) bool {
 		return false
 	}
 }
+
+func TestDrainContext(t *testing.T) {
+	ch := make(chan int, 10)
+	for i := 1; i <= 10; i++ {
+		ch <- i
+	}
+	// the owner closes the channel
+	close(ch)
+
+	sum := 0
+	drained, ctxAlive := DrainContext(context.Background(), ch, func(v int) {
+		sum += v
+	})
+	if drained != 10 || !ctxAlive {
+		t.Errorf("expected 10 drained values, got %d %t", drained, ctxAlive)
+	}
+	if sum != 55 {
+		t.Errorf("expected 55, got %d", sum)
+	}
+}
+
+func TestDrainContextRunningSender(t *testing.T) {
+	ch := make(chan int)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	// a sender which never stops on its own
+	go func() {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	drained, ctxAlive := DrainContext(ctx, ch, nil)
+	if ctxAlive {
+		t.Error("expected the context to expire")
+	}
+	if drained == 0 {
+		t.Error("expected some values to be drained")
+	}
+
+	// the sender is still running and can be stopped by its owner
+	close(stop)
+	<-stopped
+}
+
+func TestDrainContextDeadline(t *testing.T) {
+	ch := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	drained, ctxAlive := DrainContext(ctx, ch, func(int) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	if ctxAlive {
+		t.Error("expected the context to expire")
+	}
+	if drained == 0 || drained == 10 {
+		t.Errorf("expected some values to be drained, got %d", drained)
+	}
+	if len(ch) != 10-drained {
+		t.Errorf("expected %d values left, got %d", 10-drained, len(ch))
+	}
+}
@@ -3,6 +3,7 @@ package mychannel
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 type closechansignal = chan struct{}
@@ -13,9 +14,15 @@ type FanInHolder[T any] struct {
 	cancel  context.CancelFunc
 	started bool
 	closed  bool
+	// closeOnEmpty closes the output channel once there are no inputs left.
+	closeOnEmpty bool
+	// readers tracks the goroutines which might still send to out.
+	readers *sync.WaitGroup
+	obs     Observer
 
 	chans map[<-chan T]closechansignal
 	out   chan T
+	done  chan struct{}
 }
 
 // FanIn creates a new FanInHolder with the given channels. The standard
@@ -29,28 +36,60 @@ func FanIn[T any](ctx context.Context, chans ...chan T) FanInHolder[T] {
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	return FanInHolder[T]{
-		ctx:    ctx,
-		cancel: cancel,
-		mu:     &sync.RWMutex{},
-		chans:  chansMap,
-		out:    make(chan T),
+		ctx:     ctx,
+		cancel:  cancel,
+		mu:      &sync.RWMutex{},
+		readers: &sync.WaitGroup{},
+		obs:     NopObserver{},
+		chans:   chansMap,
+		out:     make(chan T),
+		done:    make(chan struct{}),
 	}
 }
 
-func (h *FanInHolder[T]) start() {
+// SetCloseOnEmpty enables closing the output channel once all inputs are
+// closed or removed, so that ranging over Chan terminates naturally. Inputs
+// added before the first call to Chan are taken into account, so add them
+// first.
+func (h *FanInHolder[T]) SetCloseOnEmpty(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closeOnEmpty = enabled
+}
+
+// SetObserver sets the observer which receives the events of the holder. It
+// only applies to the inputs read after it's called, so call it before Chan.
+func (h *FanInHolder[T]) SetObserver(obs Observer) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	h.obs = obs
+}
+
+func (h *FanInHolder[T]) start() {
+	h.mu.Lock()
+
 	if h.started {
+		h.mu.Unlock()
 		return
 	}
 	if h.closed {
+		h.mu.Unlock()
 		panic("FanInHolder already closed")
 	}
 	h.started = true
 	for ch, iamclosed := range h.chans {
 		h.read(ch, iamclosed)
 	}
+	h.obs.Subscribers(len(h.chans))
+	empty := h.closeOnEmpty && len(h.chans) == 0
+	h.mu.Unlock()
+
+	if empty {
+		h.Close()
+		return
+	}
 
 	go func() {
 		<-h.ctx.Done()
@@ -58,15 +97,36 @@ func (h *FanInHolder[T]) start() {
 	}()
 }
 
+// read reads from the input channel until it's closed or removed. Must be
+// called with the lock held.
 func (h *FanInHolder[T]) read(ch <-chan T, iamclosed closechansignal) {
+	obs := h.obs
+	h.readers.Add(1)
 	go func() {
+		defer h.readers.Done()
 		for {
 			select {
 			case v, ok := <-ch:
 				if !ok {
+					h.prune(ch)
+					return
+				}
+				obs.In()
+				select {
+				case h.out <- v:
+					obs.Out()
+					continue
+				default:
+				}
+				start := time.Now()
+				select {
+				case h.out <- v:
+					obs.Out()
+					obs.Blocked(time.Since(start))
+				case <-iamclosed:
+					obs.Dropped()
 					return
 				}
-				h.out <- v
 			case <-iamclosed:
 				return
 			}
@@ -74,40 +134,78 @@ func (h *FanInHolder[T]) read(ch <-chan T, iamclosed closechansignal) {
 	}()
 }
 
+// prune removes the closed input channel.
+func (h *FanInHolder[T]) prune(ch <-chan T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	iamclosed, ok := h.chans[ch]
+	if !ok {
+		return
+	}
+	delete(h.chans, ch)
+	close(iamclosed)
+	h.obs.Subscribers(len(h.chans))
+	if h.closeOnEmpty && len(h.chans) == 0 {
+		// Close waits for all readers, including this one
+		go h.Close()
+	}
+}
+
 func (h *FanInHolder[T]) Chan() <-chan T {
 	h.start()
 	return h.out
 }
 
+// Close stops reading from all inputs and closes the output channel.
 func (h *FanInHolder[T]) Close() {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	if h.closed {
+		h.mu.Unlock()
 		return
 	}
 	h.cancel()
 	h.closed = true
 	for _, iamclosed := range h.chans {
-		iamclosed <- struct{}{}
 		close(iamclosed)
 	}
+	h.mu.Unlock()
+
+	// the output can only be closed once no one is sending to it anymore
+	h.readers.Wait()
 	close(h.out)
+	close(h.done)
+}
+
+// Done returns a channel which is closed once the output channel is closed.
+func (h *FanInHolder[T]) Done() <-chan struct{} {
+	return h.done
 }
 
 func (h *FanInHolder[T]) Remove(ch <-chan T) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 
 	if h.closed {
+		h.mu.Unlock()
 		return
 	}
 	iamclosed, ok := h.chans[ch]
 	if !ok {
+		h.mu.Unlock()
 		return
 	}
 	delete(h.chans, ch)
 	close(iamclosed)
+	h.obs.Subscribers(len(h.chans))
+	empty := h.started && h.closeOnEmpty && len(h.chans) == 0
+	h.mu.Unlock()
+
+	if empty {
+		h.Close()
+	}
 }
 
 func (h *FanInHolder[T]) Add(ch <-chan T) {
@@ -124,5 +222,6 @@ func (h *FanInHolder[T]) Add(ch <-chan T) {
 
 	if h.started {
 		h.read(ch, h.chans[ch])
+		h.obs.Subscribers(len(h.chans))
 	}
 }
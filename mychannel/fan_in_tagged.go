@@ -0,0 +1,199 @@
+package mychannel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Tagged is a value together with the key of the source it came from.
+type Tagged[K comparable, T any] struct {
+	Source K
+	Value  T
+}
+
+type taggedSource[K comparable, T any] struct {
+	adapter  chan Tagged[K, T]
+	stop     closechansignal
+	received *atomic.Uint64
+}
+
+// TaggedFanInHolder is a FanInHolder which remembers where each value came
+// from. Every input channel is added with a caller supplied key which is sent
+// together with the value.
+type TaggedFanInHolder[K comparable, T any] struct {
+	mu      *sync.RWMutex
+	inner   FanInHolder[Tagged[K, T]]
+	order   []K
+	sources map[K]*taggedSource[K, T]
+	// received outlives the sources, so that ended sources can still be
+	// accounted for.
+	received map[K]*atomic.Uint64
+}
+
+// FanInTagged creates a new TaggedFanInHolder. Use Add to add the sources.
+func FanInTagged[K comparable, T any](ctx context.Context) *TaggedFanInHolder[K, T] {
+	return &TaggedFanInHolder[K, T]{
+		mu:       &sync.RWMutex{},
+		inner:    FanIn[Tagged[K, T]](ctx),
+		sources:  make(map[K]*taggedSource[K, T]),
+		received: make(map[K]*atomic.Uint64),
+	}
+}
+
+// Add adds the channel under the given key. Adding a key which already exists
+// does nothing.
+func (h *TaggedFanInHolder[K, T]) Add(source K, ch <-chan T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.sources[source]; ok {
+		return
+	}
+	received, ok := h.received[source]
+	if !ok {
+		received = &atomic.Uint64{}
+		h.received[source] = received
+	}
+	s := &taggedSource[K, T]{
+		adapter:  make(chan Tagged[K, T]),
+		stop:     make(closechansignal),
+		received: received,
+	}
+	h.sources[source] = s
+	h.order = append(h.order, source)
+
+	go h.tag(source, s, ch)
+	h.inner.Add(s.adapter)
+}
+
+// tag reads from the source channel and sends the tagged values to the
+// adapter channel which is read by the inner holder.
+func (h *TaggedFanInHolder[K, T]) tag(source K, s *taggedSource[K, T], ch <-chan T) {
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				// closing the adapter lets the inner holder deliver the
+				// value it might still hold before pruning it, Remove would
+				// drop it
+				h.forget(source, s)
+				close(s.adapter)
+				return
+			}
+			s.received.Add(1)
+			select {
+			case s.adapter <- Tagged[K, T]{Source: source, Value: v}:
+			case <-s.stop:
+				return
+			case <-h.inner.ctx.Done():
+				return
+			}
+		case <-s.stop:
+			return
+		case <-h.inner.ctx.Done():
+			return
+		}
+	}
+}
+
+// forget removes the source from the list of sources if it wasn't replaced
+// in the meantime.
+func (h *TaggedFanInHolder[K, T]) forget(source K, s *taggedSource[K, T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sources[source] == s {
+		h.deleteLocked(source)
+	}
+}
+
+// deleteLocked must be called with the lock held.
+func (h *TaggedFanInHolder[K, T]) deleteLocked(source K) {
+	delete(h.sources, source)
+	for i, k := range h.order {
+		if k == source {
+			h.order = append(h.order[:i], h.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Remove removes the source with the given key. A value which was read from
+// the source but not sent yet is dropped.
+func (h *TaggedFanInHolder[K, T]) Remove(source K) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.sources[source]
+	if !ok {
+		return
+	}
+	h.deleteLocked(source)
+	close(s.stop)
+	h.inner.Remove(s.adapter)
+}
+
+// SetCloseOnEmpty closes the output channel once all sources are closed or
+// removed, see FanInHolder.SetCloseOnEmpty.
+func (h *TaggedFanInHolder[K, T]) SetCloseOnEmpty(enabled bool) {
+	h.inner.SetCloseOnEmpty(enabled)
+}
+
+// Done returns a channel which is closed once the output channel is closed.
+func (h *TaggedFanInHolder[K, T]) Done() <-chan struct{} {
+	return h.inner.Done()
+}
+
+// Chan returns the channel with the tagged values from all sources.
+func (h *TaggedFanInHolder[K, T]) Chan() <-chan Tagged[K, T] {
+	return h.inner.Chan()
+}
+
+func (h *TaggedFanInHolder[K, T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range h.sources {
+		close(s.stop)
+	}
+	clear(h.sources)
+	h.order = nil
+	h.inner.Close()
+}
+
+// Sources returns the keys of the sources in the order they were added.
+// Sources whose channel was closed are not listed.
+func (h *TaggedFanInHolder[K, T]) Sources() []K {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return append([]K(nil), h.order...)
+}
+
+// Received returns the number of values received from the source. The
+// counts of closed or removed sources are kept, and a source added again
+// under the same key continues counting.
+func (h *TaggedFanInHolder[K, T]) Received(source K) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	received, ok := h.received[source]
+	if !ok {
+		return 0
+	}
+	return received.Load()
+}
+
+// Stats returns the number of values received from every source ever added,
+// including the closed or removed ones.
+func (h *TaggedFanInHolder[K, T]) Stats() map[K]uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make(map[K]uint64, len(h.received))
+	for k, received := range h.received {
+		stats[k] = received.Load()
+	}
+	return stats
+}
@@ -0,0 +1,94 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFanInTagged(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	h := FanInTagged[string, int](ctx)
+	defer h.Close()
+
+	a := make(chan int)
+	b := make(chan int)
+	h.Add("a", a)
+	h.Add("b", b)
+	// adding the same key does nothing
+	h.Add("a", b)
+
+	sources := h.Sources()
+	if len(sources) != 2 || sources[0] != "a" || sources[1] != "b" {
+		t.Fatalf("expected [a b], got %v", sources)
+	}
+
+	out := h.Chan()
+	go func() {
+		a <- 1
+		b <- 10
+		a <- 2
+	}()
+
+	sum := map[string]int{}
+	for range 3 {
+		v := <-out
+		sum[v.Source] += v.Value
+	}
+	if sum["a"] != 3 {
+		t.Errorf("expected 3 from a, got %d", sum["a"])
+	}
+	if sum["b"] != 10 {
+		t.Errorf("expected 10 from b, got %d", sum["b"])
+	}
+
+	if n := h.Received("a"); n != 2 {
+		t.Errorf("expected 2 values from a, got %d", n)
+	}
+	stats := h.Stats()
+	if stats["a"] != 2 || stats["b"] != 1 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	h.Remove("a")
+	if n := h.Received("a"); n != 2 {
+		t.Errorf("expected the removed source to keep its 2 values, got %d", n)
+	}
+
+	// closing the source channel removes it
+	close(b)
+	waitFor(func() bool { return len(h.Sources()) == 0 })
+	if sources := h.Sources(); len(sources) != 0 {
+		t.Errorf("expected no sources, got %v", sources)
+	}
+	stats = h.Stats()
+	if len(stats) != 2 || stats["a"] != 2 || stats["b"] != 1 {
+		t.Errorf("expected the ended sources in the stats, got %v", stats)
+	}
+}
+
+func TestFanInTaggedClosedSourceDeliversAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	for range 20 {
+		h := FanInTagged[string, int](ctx)
+		h.SetCloseOnEmpty(true)
+		a := make(chan int, 3)
+		a <- 1
+		a <- 2
+		a <- 3
+		close(a)
+		h.Add("a", a)
+
+		var got []int
+		for v := range h.Chan() {
+			got = append(got, v.Value)
+		}
+		if len(got) != 3 {
+			t.Fatalf("expected 3 values, got %v", got)
+		}
+	}
+}
@@ -141,3 +141,89 @@ func TestFanInAddingAndRemovingChannels(t *testing.T) {
 	// calling close again does nothing
 	h.Close()
 }
+
+func TestFanInCloseOnEmpty(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	ch1 := make(chan int)
+	ch2 := make(chan int)
+	h := FanIn(ctx, ch1, ch2)
+	h.SetCloseOnEmpty(true)
+
+	go func() {
+		defer close(ch1)
+		ch1 <- 1
+		ch1 <- 2
+	}()
+	go func() {
+		defer close(ch2)
+		ch2 <- 3
+	}()
+
+	sum := 0
+	for v := range h.Chan() {
+		sum += v
+	}
+	if sum != 6 {
+		t.Errorf("expected 6, got %d", sum)
+	}
+
+	select {
+	case <-h.Done():
+	case <-ctx.Done():
+		t.Fatal("expected the holder to be done")
+	}
+	if ctx.Err() != nil {
+		t.Error("expected the output to close before the context")
+	}
+}
+
+func TestFanInPrunesClosedChannels(t *testing.T) {
+	t.Parallel()
+	h := FanIn[int](context.Background())
+	defer h.Close()
+
+	ch := make(chan int)
+	h.Add(ch)
+	out := h.Chan()
+	close(ch)
+
+	for range 5 {
+		h.mu.RLock()
+		n := len(h.chans)
+		h.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.mu.RLock()
+	n := len(h.chans)
+	h.mu.RUnlock()
+	if n != 0 {
+		t.Fatalf("expected the closed channel to be pruned, got %d channels", n)
+	}
+
+	// without the close on empty policy the output stays open
+	select {
+	case <-out:
+		t.Error("expected the output to stay open")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestFanInCloseWhileSending(t *testing.T) {
+	t.Parallel()
+	ch := make(chan int, 1)
+	ch <- 1
+	h := FanIn(context.Background(), ch)
+	h.Chan()
+	// give the reader time to block on sending to the output
+	time.Sleep(10 * time.Millisecond)
+
+	// must neither block nor panic
+	h.Close()
+	<-h.Done()
+}
@@ -3,6 +3,175 @@ package mychannel
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what happens with a value when the subscriber's
+// channel is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the subscriber reads from the channel. A slow
+	// subscriber slows down all the others.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the value which is being sent.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest value in the channel's buffer to
+	// make room for the new one. Unbuffered channels behave like
+	// OverflowDropNewest as there is nothing to drop.
+	OverflowDropOldest
+	// OverflowDisconnect closes the subscriber's channel and removes it. The
+	// values already in the buffer can still be read.
+	OverflowDisconnect
+)
+
+type subscriber[T any] struct {
+	id uint64
+	// mu is held while sending to ch so that the channel is never closed
+	// during a send.
+	mu      sync.Mutex
+	ch      chan T
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+	closed  bool
+	// removed is closed when the subscriber is removed to unblock a pending
+	// send.
+	removed chan struct{}
+	// replayed is closed once the replayed values which didn't fit in the
+	// buffer are sent. It's nil if there are none.
+	replayed chan struct{}
+}
+
+func newSubscriber[T any](id uint64, chsize int, policy OverflowPolicy) *subscriber[T] {
+	return &subscriber[T]{
+		id:      id,
+		ch:      make(chan T, chsize),
+		policy:  policy,
+		removed: make(chan struct{}),
+	}
+}
+
+// send sends the value respecting the overflow policy. It returns false if
+// the subscriber should be disconnected.
+func (s *subscriber[T]) send(ctx context.Context, v T, obs Observer) bool {
+	// the new values go after the replayed ones
+	if s.replayed != nil {
+		select {
+		case <-s.replayed:
+		case <-s.removed:
+			obs.Dropped()
+			return true
+		case <-ctx.Done():
+			obs.Dropped()
+			return true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sendLocked(ctx, v, obs)
+}
+
+// sendLocked is send for the callers which already hold the lock and don't
+// wait for the replay.
+func (s *subscriber[T]) sendLocked(ctx context.Context, v T, obs Observer) bool {
+	if s.closed {
+		return true
+	}
+
+	select {
+	case s.ch <- v:
+		obs.Out()
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case OverflowDropNewest:
+		s.drop(obs)
+	case OverflowDropOldest:
+		if cap(s.ch) == 0 {
+			s.drop(obs)
+			return true
+		}
+		for {
+			select {
+			case s.ch <- v:
+				obs.Out()
+				return true
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.drop(obs)
+			default:
+			}
+		}
+	case OverflowDisconnect:
+		s.drop(obs)
+		return false
+	default:
+		start := time.Now()
+		select {
+		case s.ch <- v:
+			obs.Out()
+		case <-s.removed:
+			obs.Dropped()
+		case <-ctx.Done():
+			obs.Dropped()
+		}
+		obs.Blocked(time.Since(start))
+	}
+	return true
+}
+
+func (s *subscriber[T]) drop(obs Observer) {
+	s.dropped.Add(1)
+	obs.Dropped()
+}
+
+// close closes the subscriber's channel. If drain is true, the values left in
+// the buffer are discarded.
+func (s *subscriber[T]) close(drain bool) {
+	if s.isRemoved() {
+		return
+	}
+	close(s.removed)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if drain {
+		Drain(s.ch)
+		return
+	}
+	close(s.ch)
+}
+
+// isRemoved returns true once the subscriber has been removed.
+func (s *subscriber[T]) isRemoved() bool {
+	select {
+	case <-s.removed:
+		return true
+	default:
+		return false
+	}
+}
+
+type retainedValue[T any] struct {
+	v  T
+	at time.Time
+}
+
+type fanOutMode int
+
+const (
+	fanOutBroadcast fanOutMode = iota
+	fanOutPartitioned
+	fanOutRoundRobin
+	fanOutLeastLoaded
 )
 
 type FanOutHolder[T any] struct {
@@ -11,27 +180,155 @@ type FanOutHolder[T any] struct {
 	cancel  context.CancelFunc
 	started bool
 	closed  bool
+	mode    fanOutMode
+
+	in     <-chan T
+	subs   []*subscriber[T]
+	nextID uint64
+	// disconnected keeps the drop counts of the subscribers removed by
+	// OverflowDisconnect until Dropped reports them.
+	disconnected map[<-chan T]uint64
+
+	// used only in the partitioned mode
+	keyfn func(T) string
+	ring  *hashRing[T]
 
-	in   <-chan T
-	outs []chan T
+	// next is the position of the next subscriber in the load balancing
+	// modes.
+	next int
+
+	obs Observer
+
+	// replaying tracks the goroutines sending the replayed values.
+	replaying *sync.WaitGroup
+
+	// see SetReplay
+	replayN      int
+	replayWindow time.Duration
+	retained     []retainedValue[T]
 }
 
 func FanOut[T any](ctx context.Context, in chan T) FanOutHolder[T] {
 	ctx, cancel := context.WithCancel(ctx)
 	return FanOutHolder[T]{
-		mu:     &sync.RWMutex{},
-		ctx:    ctx,
-		cancel: cancel,
-		in:     in,
+		mu:           &sync.RWMutex{},
+		ctx:          ctx,
+		cancel:       cancel,
+		in:           in,
+		obs:          NopObserver{},
+		disconnected: make(map[<-chan T]uint64),
+		replaying:    &sync.WaitGroup{},
 	}
 }
 
+// SetObserver sets the observer which receives the events of the holder.
+func (h *FanOutHolder[T]) SetObserver(obs Observer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.obs = obs
+}
+
+// FanOutByKey creates a FanOutHolder which sends every value to exactly one
+// output channel instead of all of them. The output channel is picked by the
+// key returned from keyfn using consistent hashing, so values with the same
+// key always go to the same output channel and keep their order. Adding or
+// removing an output channel moves only a small part of the keys. Values
+// read while there are no output channels are dropped.
+func FanOutByKey[T any](ctx context.Context, in chan T, keyfn func(T) string) FanOutHolder[T] {
+	h := FanOut(ctx, in)
+	h.mode = fanOutPartitioned
+	h.keyfn = keyfn
+	h.ring = &hashRing[T]{}
+	return h
+}
+
+// FanOutRoundRobin creates a FanOutHolder which sends every value to exactly
+// one output channel, taking turns. Output channels added or removed while
+// running join or leave the rotation immediately. Values read while there are
+// no output channels are dropped.
+func FanOutRoundRobin[T any](ctx context.Context, in chan T) FanOutHolder[T] {
+	h := FanOut(ctx, in)
+	h.mode = fanOutRoundRobin
+	return h
+}
+
+// FanOutLeastLoaded creates a FanOutHolder which sends every value to exactly
+// one output channel, the one with the fewest values waiting in its buffer.
+// Ties are broken by taking turns. Values read while there are no output
+// channels are dropped.
+func FanOutLeastLoaded[T any](ctx context.Context, in chan T) FanOutHolder[T] {
+	h := FanOut(ctx, in)
+	h.mode = fanOutLeastLoaded
+	return h
+}
+
+// SetReplay makes the holder remember the last n values, or the values from
+// the last window, or both when both are set, and send them to every output
+// channel added later before any new values. Use 0 to disable a limit. It
+// only works in the broadcast mode created by FanOut.
+func (h *FanOutHolder[T]) SetReplay(n int, window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.replayN = n
+	h.replayWindow = window
+	h.pruneRetained(time.Now())
+}
+
+// SetBehavior makes every output channel added later receive the latest value
+// first, starting with the initial value until a new value is read. It's
+// useful for state, like configuration, where a late subscriber needs the
+// current value.
+func (h *FanOutHolder[T]) SetBehavior(initial T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.replayN = 1
+	h.replayWindow = 0
+	h.retained = []retainedValue[T]{{v: initial, at: time.Now()}}
+}
+
+func (h *FanOutHolder[T]) replays() bool {
+	return h.mode == fanOutBroadcast && (h.replayN > 0 || h.replayWindow > 0)
+}
+
+// retain remembers the value for the replay. Must be called with the lock
+// held.
+func (h *FanOutHolder[T]) retain(v T) {
+	if !h.replays() {
+		return
+	}
+	now := time.Now()
+	h.retained = append(h.retained, retainedValue[T]{v: v, at: now})
+	h.pruneRetained(now)
+}
+
+// pruneRetained forgets the values which are over the replay limits. Must be
+// called with the lock held.
+func (h *FanOutHolder[T]) pruneRetained(now time.Time) {
+	if !h.replays() {
+		h.retained = nil
+		return
+	}
+	drop := 0
+	if h.replayN > 0 && len(h.retained) > h.replayN {
+		drop = len(h.retained) - h.replayN
+	}
+	if h.replayWindow > 0 {
+		for drop < len(h.retained) && now.Sub(h.retained[drop].at) > h.replayWindow {
+			drop++
+		}
+	}
+	h.retained = append(h.retained[:0], h.retained[drop:]...)
+}
+
 // Start starts the fan-out process. It reads from the input channel and sends
-// the value to all output channels. It will stop when the input channel is
-// closed or the context is cancelled.
-// Important: depending on the channel size, the order of the values may not be
-// preserved. Especially when the channel size is 0, the value will be sent
-// out of order if no one is reading from the channel.
+// the value to all output channels (or just one for FanOutByKey,
+// FanOutRoundRobin and FanOutLeastLoaded), one after another, in the order
+// they were read. What happens when an output channel is full depends on the overflow
+// policy given to AddWithPolicy. It will stop when the input channel is closed
+// or the context is cancelled.
 func (h *FanOutHolder[T]) Start() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -51,37 +348,84 @@ func (h *FanOutHolder[T]) Start() {
 			if !ok || !ctxalive {
 				return
 			}
-			h.mu.RLock()
-			for _, ch := range h.outs {
-				select {
-				case ch <- v:
-				default: // if no one is reading from the channel, send it later, out of order
-					go func() {
-						ch <- v
-					}()
+			// the value is retained and the subscribers are picked at
+			// once, so that a new subscriber gets it either from the
+			// replay or from here, never both
+			h.mu.Lock()
+			h.retain(v)
+			subs := h.targets(v)
+			obs := h.obs
+			h.mu.Unlock()
+
+			obs.In()
+			if len(subs) == 0 {
+				obs.Dropped()
+			}
+			for _, s := range subs {
+				if !s.send(h.ctx, v, obs) {
+					h.disconnect(s)
 				}
 			}
-			h.mu.RUnlock()
 		}
 	}()
 }
 
+// targets returns the subscribers which should receive the value. Must be
+// called with the lock held.
+func (h *FanOutHolder[T]) targets(v T) []*subscriber[T] {
+	switch h.mode {
+	case fanOutPartitioned:
+		if s := h.ring.get(h.keyfn(v)); s != nil {
+			return []*subscriber[T]{s}
+		}
+		return nil
+	case fanOutRoundRobin, fanOutLeastLoaded:
+		if len(h.subs) == 0 {
+			return nil
+		}
+		idx := h.next % len(h.subs)
+		if h.mode == fanOutLeastLoaded {
+			for i := range len(h.subs) {
+				j := (h.next + i) % len(h.subs)
+				if len(h.subs[j].ch) < len(h.subs[idx].ch) {
+					idx = j
+				}
+			}
+		}
+		h.next = idx + 1
+		return []*subscriber[T]{h.subs[idx]}
+	default:
+		return append([]*subscriber[T](nil), h.subs...)
+	}
+}
+
 func (h *FanOutHolder[T]) Close() {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	if h.closed {
+		h.mu.Unlock()
 		return
 	}
 	h.closed = true
 
 	h.cancel()
-	for _, ch := range h.outs {
-		close(ch)
+	for _, s := range h.subs {
+		s.close(false)
 	}
+	h.mu.Unlock()
+
+	// the replays stop as the subscribers are closed
+	h.replaying.Wait()
 }
 
+// Add adds a new output channel with the given buffer size. When the channel
+// is full, the fan-out waits until the subscriber reads from it.
 func (h *FanOutHolder[T]) Add(chsize int) <-chan T {
+	return h.AddWithPolicy(chsize, OverflowBlock)
+}
+
+// AddWithPolicy adds a new output channel with the given buffer size and the
+// policy which decides what happens when the channel is full.
+func (h *FanOutHolder[T]) AddWithPolicy(chsize int, policy OverflowPolicy) <-chan T {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -92,12 +436,61 @@ func (h *FanOutHolder[T]) Add(chsize int) <-chan T {
 		panic("channel size must be non-negative")
 	}
 
-	ch := make(chan T, chsize)
+	h.nextID++
+	s := newSubscriber[T](h.nextID, chsize, policy)
 
-	h.outs = append(h.outs, ch)
-	return ch
+	h.subs = append(h.subs, s)
+	if h.ring != nil {
+		h.ring.add(s)
+	}
+	h.obs.Subscribers(len(h.subs))
+	h.replay(s)
+	return s.ch
 }
 
+// replay sends the retained values to the new subscriber. The values which
+// fit in the buffer are sent right away, the rest by a goroutine which the
+// new values wait for, see subscriber.send. Must be called with the lock held,
+// before the subscriber gets any new values.
+func (h *FanOutHolder[T]) replay(s *subscriber[T]) {
+	h.pruneRetained(time.Now())
+	values := make([]T, 0, len(h.retained))
+	for _, r := range h.retained {
+		values = append(values, r.v)
+	}
+
+	obs := h.obs
+	for len(values) > 0 && len(s.ch) < cap(s.ch) {
+		s.ch <- values[0]
+		obs.Out()
+		values = values[1:]
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	s.replayed = make(chan struct{})
+	h.replaying.Add(1)
+	go func() {
+		defer h.replaying.Done()
+		defer close(s.replayed)
+		for _, v := range values {
+			if s.isRemoved() {
+				return
+			}
+			s.mu.Lock()
+			ok := s.sendLocked(h.ctx, v, obs)
+			s.mu.Unlock()
+			if !ok {
+				h.disconnect(s)
+				return
+			}
+		}
+	}()
+}
+
+// Remove removes the output channel, closes it and discards the values left
+// in it.
 func (h *FanOutHolder[T]) Remove(ch <-chan T) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -106,16 +499,56 @@ func (h *FanOutHolder[T]) Remove(ch <-chan T) {
 		return
 	}
 
-	idx := -1
-	for i, c := range h.outs {
-		if c == ch {
-			idx = i
-			break
+	s := h.pop(ch)
+	if s == nil {
+		return
+	}
+	s.close(true)
+}
+
+// Dropped returns the number of values which were not delivered to the output
+// channel because of its overflow policy. After the output channel was
+// disconnected by OverflowDisconnect, the final count is kept until it's read
+// once, later calls return 0.
+func (h *FanOutHolder[T]) Dropped(ch <-chan T) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range h.subs {
+		if s.ch == ch {
+			return s.dropped.Load()
 		}
 	}
-	if idx == -1 {
+	dropped := h.disconnected[ch]
+	delete(h.disconnected, ch)
+	return dropped
+}
+
+func (h *FanOutHolder[T]) disconnect(s *subscriber[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	if h.pop(s.ch) == nil {
 		return
 	}
-	Drain(h.outs[idx])
-	h.outs = append(h.outs[:idx], h.outs[idx+1:]...)
+	s.close(false)
+	h.disconnected[s.ch] = s.dropped.Load()
+}
+
+// pop removes the subscriber from the list. Must be called with the lock held.
+func (h *FanOutHolder[T]) pop(ch <-chan T) *subscriber[T] {
+	for i, s := range h.subs {
+		if s.ch == ch {
+			h.subs = append(h.subs[:i], h.subs[i+1:]...)
+			if h.ring != nil {
+				h.ring.remove(s)
+			}
+			h.obs.Subscribers(len(h.subs))
+			return s
+		}
+	}
+	return nil
 }
@@ -2,7 +2,11 @@ package mychannel
 
 import (
 	"context"
+	"strconv"
 	"testing"
+	"time"
+
+	"github.com/vizualni/mystds/mytest"
 )
 
 func TestFanOut(t *testing.T) {
@@ -58,3 +62,366 @@ func TestFanOut(t *testing.T) {
 		t.Errorf("expected 4, got %d", val3)
 	}
 }
+
+func TestFanOutOverflowPolicies(t *testing.T) {
+	type testcase struct {
+		policy   OverflowPolicy
+		expected []int
+		dropped  uint64
+		closed   bool
+	}
+	tests := mytest.NewTests[testcase](t)
+
+	tests.AddParallel("drop newest", testcase{
+		policy:   OverflowDropNewest,
+		expected: []int{1, 2},
+		dropped:  3,
+	})
+	tests.AddParallel("drop oldest", testcase{
+		policy:   OverflowDropOldest,
+		expected: []int{4, 5},
+		dropped:  3,
+	})
+	tests.AddParallel("disconnect", testcase{
+		policy:   OverflowDisconnect,
+		expected: []int{1, 2},
+		dropped:  1,
+		closed:   true,
+	})
+
+	tests.Test(func(t *testing.T, tc testcase) {
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+		in := make(chan int)
+		h := FanOut(ctx, in)
+		defer h.Close()
+		slow := h.AddWithPolicy(2, tc.policy)
+		fast := h.Add(5)
+		h.Start()
+
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+		// the fast subscriber receiving everything means that the slow
+		// one has already been handled for every value
+		for i := 1; i <= 5; i++ {
+			if v := <-fast; v != i {
+				t.Errorf("expected %d, got %d", i, v)
+			}
+		}
+
+		if dropped := h.Dropped(slow); dropped != tc.dropped {
+			t.Errorf("expected %d dropped values, got %d", tc.dropped, dropped)
+		}
+		if tc.closed {
+			// the count of a disconnected channel is forgotten once read
+			if dropped := h.Dropped(slow); dropped != 0 {
+				t.Errorf("expected the count to be forgotten, got %d", dropped)
+			}
+		}
+		for _, exp := range tc.expected {
+			if v := <-slow; v != exp {
+				t.Errorf("expected %d, got %d", exp, v)
+			}
+		}
+		if tc.closed {
+			if _, ok := <-slow; ok {
+				t.Errorf("expected channel to be closed, but it is not")
+			}
+		}
+	})
+}
+
+func TestFanOutBlockingPreservesOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOut(ctx, in)
+	defer h.Close()
+	out := h.Add(0)
+	h.Start()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			if !WriteOne(ctx, in, i) {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if v := <-out; v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+
+	// removing a subscriber which is blocked must not deadlock
+	go func() {
+		WriteOne(ctx, in, 100)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	h.Remove(out)
+	if _, ok := <-out; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
+
+func TestFanOutByKey(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan string)
+	h := FanOutByKey(ctx, in, func(v string) string { return v })
+	defer h.Close()
+
+	outs := []<-chan string{h.Add(100), h.Add(100), h.Add(100)}
+	h.Start()
+
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = "user-" + strconv.Itoa(i)
+	}
+
+	// owners maps every key to the index of the output channel receiving it
+	route := func() map[string]int {
+		for _, k := range keys {
+			in <- k
+		}
+		owners := make(map[string]int, len(keys))
+		for len(owners) < len(keys) {
+			for i, out := range outs {
+				if out == nil {
+					continue
+				}
+				select {
+				case k := <-out:
+					owners[k] = i
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}
+		return owners
+	}
+
+	first := route()
+	used := map[int]bool{}
+	for _, i := range first {
+		used[i] = true
+	}
+	if len(used) != 3 {
+		t.Errorf("expected keys to be spread over 3 outputs, got %d", len(used))
+	}
+
+	// the same keys go to the same outputs
+	second := route()
+	for k, i := range first {
+		if second[k] != i {
+			t.Errorf("expected %s to stay on output %d, got %d", k, i, second[k])
+		}
+	}
+
+	// only the keys of the removed output move
+	h.Remove(outs[1])
+	outs[1] = nil
+	third := route()
+	for k, i := range first {
+		if i != 1 && third[k] != i {
+			t.Errorf("expected %s to stay on output %d, got %d", k, i, third[k])
+		}
+		if third[k] == 1 {
+			t.Errorf("expected %s to move from the removed output", k)
+		}
+	}
+}
+
+func TestFanOutRoundRobin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOutRoundRobin(ctx, in)
+	defer h.Close()
+	out1 := h.Add(10)
+	out2 := h.Add(10)
+	h.Start()
+
+	for i := 0; i < 4; i++ {
+		in <- i
+	}
+	for _, exp := range []int{0, 2} {
+		if v := <-out1; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+	for _, exp := range []int{1, 3} {
+		if v := <-out2; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+
+	// a new subscriber joins the rotation
+	out3 := h.Add(10)
+	for i := 4; i < 7; i++ {
+		in <- i
+	}
+	got := map[int]int{}
+	for i, out := range []<-chan int{out1, out2, out3} {
+		got[i] = <-out
+	}
+	if len(got) != 3 {
+		t.Errorf("expected every subscriber to receive a value, got %v", got)
+	}
+}
+
+func TestFanOutLeastLoaded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOutLeastLoaded(ctx, in)
+	defer h.Close()
+	busy := h.Add(10)
+	idle := h.Add(10)
+	h.Start()
+
+	// waits for the dispatcher to deliver the values
+	waitLen := func(total int) {
+		for range 100 {
+			if len(busy)+len(idle) == total {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		in <- i
+	}
+	waitLen(4)
+	// nobody reads, so the values are spread evenly
+	if len(busy) != 2 || len(idle) != 2 {
+		t.Fatalf("expected 2 values each, got %d and %d", len(busy), len(idle))
+	}
+
+	// the idle subscriber empties its buffer and gets the next values
+	<-idle
+	<-idle
+	in <- 4
+	in <- 5
+	waitLen(4)
+	if len(busy) != 2 || len(idle) != 2 {
+		t.Errorf("expected 2 values each, got %d and %d", len(busy), len(idle))
+	}
+	for _, exp := range []int{4, 5} {
+		if v := <-idle; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+}
+
+func TestFanOutReplay(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOut(ctx, in)
+	defer h.Close()
+	h.SetReplay(2, 0)
+	first := h.Add(10)
+	h.Start()
+
+	for i := 1; i <= 3; i++ {
+		in <- i
+	}
+	for i := 1; i <= 3; i++ {
+		<-first
+	}
+
+	// the late subscriber gets the last two values first, then the new ones
+	late := h.Add(0)
+	go func() {
+		in <- 4
+	}()
+	for _, exp := range []int{2, 3, 4} {
+		if v := <-late; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+}
+
+func TestFanOutReplayOverBuffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOut(ctx, in)
+	h.SetReplay(3, 0)
+	h.Start()
+
+	for i := 1; i <= 3; i++ {
+		in <- i
+	}
+
+	// only the first replayed value fits in the buffer
+	late := h.Add(1)
+	if n := len(late); n != 1 {
+		t.Errorf("expected 1 value in the buffer, got %d", n)
+	}
+	go func() {
+		in <- 4
+	}()
+	for _, exp := range []int{1, 2, 3, 4} {
+		if v := <-late; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+
+	// closing stops an unfinished replay
+	stuck := h.Add(0)
+	h.Close()
+	for range stuck {
+	}
+}
+
+func TestFanOutReplayWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOut(ctx, in)
+	defer h.Close()
+	h.SetReplay(0, 30*time.Millisecond)
+	h.Start()
+
+	in <- 1
+	time.Sleep(50 * time.Millisecond)
+	in <- 2
+	// make sure 2 has been handled
+	in <- 3
+
+	late := h.Add(10)
+	for _, exp := range []int{2, 3} {
+		if v := <-late; v != exp {
+			t.Errorf("expected %d, got %d", exp, v)
+		}
+	}
+}
+
+func TestFanOutBehavior(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan string)
+	h := FanOut(ctx, in)
+	defer h.Close()
+	h.SetBehavior("initial")
+	h.Start()
+
+	early := h.Add(1)
+	if v := <-early; v != "initial" {
+		t.Errorf("expected initial, got %s", v)
+	}
+
+	in <- "updated"
+	if v := <-early; v != "updated" {
+		t.Errorf("expected updated, got %s", v)
+	}
+
+	late := h.Add(1)
+	if v := <-late; v != "updated" {
+		t.Errorf("expected updated, got %s", v)
+	}
+}
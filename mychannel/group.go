@@ -0,0 +1,64 @@
+package mychannel
+
+import (
+	"context"
+	"sync"
+)
+
+// group runs goroutines which share a context and report the first error,
+// like errgroup. PoolHolder, ResultFanInHolder and Pipeline are built on it.
+type group struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// keepGoing keeps the context alive when a goroutine fails, only the
+	// first error is recorded.
+	keepGoing bool
+
+	errOnce sync.Once
+	err     error
+}
+
+func newGroup(ctx context.Context) *group {
+	gctx, cancel := context.WithCancel(ctx)
+	return &group{
+		parent: ctx,
+		ctx:    gctx,
+		cancel: cancel,
+	}
+}
+
+func (g *group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		if !g.keepGoing {
+			g.cancel()
+		}
+	})
+}
+
+// spawn runs f in a new goroutine. The error returned by f fails the group.
+// If f returns because the parent context was cancelled, the parent's error
+// is recorded right away, so a group which finished cleanly doesn't report
+// it when the parent is cancelled later.
+func (g *group) spawn(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.fail(err)
+			return
+		}
+		if err := g.parent.Err(); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// wait waits for all goroutines to finish and returns the first error.
+func (g *group) wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
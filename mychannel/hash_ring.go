@@ -0,0 +1,71 @@
+package mychannel
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// ringReplicas is the number of points every subscriber gets on the ring.
+// More points spread the keys more evenly.
+const ringReplicas = 64
+
+type ringPoint[T any] struct {
+	hash uint32
+	sub  *subscriber[T]
+}
+
+// hashRing is a consistent hash ring. Adding or removing a subscriber only
+// moves the keys which belong to the neighbouring points.
+type hashRing[T any] struct {
+	points []ringPoint[T]
+}
+
+func ringHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	// FNV alone spreads short keys which differ only in the last few bytes
+	// poorly, so the bits are mixed once more (murmur3 finalizer).
+	x := h.Sum32()
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return x
+}
+
+func (r *hashRing[T]) add(s *subscriber[T]) {
+	for i := range ringReplicas {
+		r.points = append(r.points, ringPoint[T]{
+			hash: ringHash(strconv.FormatUint(s.id, 10) + "-" + strconv.Itoa(i)),
+			sub:  s,
+		})
+	}
+	slices.SortFunc(r.points, func(a, b ringPoint[T]) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+}
+
+func (r *hashRing[T]) remove(s *subscriber[T]) {
+	r.points = slices.DeleteFunc(r.points, func(p ringPoint[T]) bool {
+		return p.sub == s
+	})
+}
+
+// get returns the subscriber responsible for the key or nil if the ring is
+// empty.
+func (r *hashRing[T]) get(key string) *subscriber[T] {
+	if len(r.points) == 0 {
+		return nil
+	}
+	h := ringHash(key)
+	idx, _ := slices.BinarySearchFunc(r.points, h, func(p ringPoint[T], h uint32) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return r.points[idx].sub
+}
@@ -0,0 +1,74 @@
+package mychannel
+
+import (
+	"context"
+	"iter"
+)
+
+// Seq returns an iterator over the values from the channel, so it can be used
+// with range-over-func code like slices.Collect. The iteration stops when the
+// channel is closed or the context is cancelled. Breaking out of the loop
+// only stops reading, use SeqCancel to also stop the producer.
+func Seq[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, ok, ctxAlive := ReadOne(ctx, ch)
+			if !ok || !ctxAlive {
+				return
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// SeqCancel is Seq which calls cancel once the iteration ends, including when
+// the consumer breaks out of the loop early. Pass the cancel function of the
+// context the producer uses, or the stop function returned by FromSeq, so the
+// producer stops instead of blocking forever on a channel nobody reads.
+func SeqCancel[T any](ctx context.Context, ch <-chan T, cancel func()) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		defer cancel()
+		for v := range Seq(ctx, ch) {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Seq2 is Seq with the index of every value.
+func Seq2[T any](ctx context.Context, ch <-chan T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for v := range Seq(ctx, ch) {
+			if !yield(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// FromSeq sends the values from the iterator to a channel with the given
+// buffer size. The channel is closed when the iterator is done. Calling stop
+// or cancelling the context stops the iterator, so a consumer which stops
+// reading early must call stop, e.g. through SeqCancel, to not leave the
+// goroutine blocked. stop can be called more than once.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T], bufsize int) (ch <-chan T, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	ret := make(chan T, bufsize)
+
+	go func() {
+		defer cancel()
+		defer close(ret)
+		for v := range seq {
+			if !WriteOne(ctx, ret, v) {
+				return
+			}
+		}
+	}()
+
+	return ret, cancel
+}
@@ -0,0 +1,112 @@
+package mychannel
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSeq(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	got := slices.Collect(Seq(ctx, ch))
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", got)
+	}
+}
+
+func TestSeq2(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	ch := make(chan string, 3)
+	ch <- "a"
+	ch <- "b"
+	ch <- "c"
+	close(ch)
+
+	for i, v := range Seq2(ctx, ch) {
+		if expected := string(rune('a' + i)); v != expected {
+			t.Errorf("expected %s at %d, got %s", expected, i, v)
+		}
+		if i == 1 {
+			break
+		}
+	}
+	// breaking out of the loop leaves the rest in the channel
+	if v := <-ch; v != "c" {
+		t.Errorf("expected c, got %s", v)
+	}
+}
+
+func TestFromSeq(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	out, stop := FromSeq(ctx, slices.Values([]int{1, 2, 3}), 0)
+	defer stop()
+	got, _ := ReadAll(ctx, out)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", got)
+	}
+}
+
+func TestFromSeqStopsProducer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	infinite := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	out, stop := FromSeq(ctx, infinite, 0)
+	defer stop()
+	for v := range out {
+		if v == 10 {
+			break
+		}
+	}
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected the producer to stop")
+	}
+}
+
+func TestSeqCancelStopsProducer(t *testing.T) {
+	stopped := make(chan struct{})
+	infinite := func(yield func(int) bool) {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	// no cancel from the outside, breaking out of the loop is enough
+	out, stop := FromSeq(context.Background(), infinite, 0)
+	for v := range SeqCancel(context.Background(), out, stop) {
+		if v == 10 {
+			break
+		}
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected the producer to stop")
+	}
+}
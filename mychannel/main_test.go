@@ -0,0 +1,11 @@
+package mychannel
+
+import (
+	"testing"
+
+	"github.com/vizualni/mystds/mytest"
+)
+
+func TestMain(m *testing.M) {
+	mytest.VerifyTestMain(m)
+}
@@ -0,0 +1,82 @@
+package mychannel
+
+import (
+	"expvar"
+	"time"
+)
+
+// Observer receives events from the channel operators, e.g. to export them as
+// metrics. The methods are called from the operators' goroutines, so they
+// must be safe for concurrent use and fast.
+type Observer interface {
+	// In is called for every value read from an input channel.
+	In()
+	// Out is called for every value sent to an output channel.
+	Out()
+	// Dropped is called for every value which was not delivered.
+	Dropped()
+	// Blocked is called with the time spent waiting on a full output
+	// channel.
+	Blocked(d time.Duration)
+	// Subscribers is called with the new number of inputs (FanIn) or
+	// outputs (FanOut) whenever it changes.
+	Subscribers(n int)
+}
+
+// NopObserver ignores all events.
+type NopObserver struct{}
+
+func (NopObserver) In()                   {}
+func (NopObserver) Out()                  {}
+func (NopObserver) Dropped()              {}
+func (NopObserver) Blocked(time.Duration) {}
+func (NopObserver) Subscribers(int)       {}
+
+// ExpvarObserver publishes the events as counters through expvar, so they are
+// visible on /debug/vars.
+type ExpvarObserver struct {
+	in          *expvar.Int
+	out         *expvar.Int
+	dropped     *expvar.Int
+	blockedNs   *expvar.Int
+	subscribers *expvar.Int
+}
+
+// NewExpvarObserver creates an ExpvarObserver publishing a map with the given
+// name. Like expvar.NewMap, it panics if the name is already used.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	o := &ExpvarObserver{
+		in:          new(expvar.Int),
+		out:         new(expvar.Int),
+		dropped:     new(expvar.Int),
+		blockedNs:   new(expvar.Int),
+		subscribers: new(expvar.Int),
+	}
+	m := expvar.NewMap(name)
+	m.Set("in", o.in)
+	m.Set("out", o.out)
+	m.Set("dropped", o.dropped)
+	m.Set("blocked_ns", o.blockedNs)
+	m.Set("subscribers", o.subscribers)
+	return o
+}
+
+func (o *ExpvarObserver) In() {
+	o.in.Add(1)
+}
+
+func (o *ExpvarObserver) Out() {
+	o.out.Add(1)
+}
+
+func (o *ExpvarObserver) Dropped() {
+	o.dropped.Add(1)
+}
+
+func (o *ExpvarObserver) Blocked(d time.Duration) {
+	o.blockedNs.Add(int64(d))
+}
+
+func (o *ExpvarObserver) Subscribers(n int) {
+	o.subscribers.Set(int64(n))
+}
@@ -0,0 +1,167 @@
+package mychannel
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vizualni/mystds/myrand"
+)
+
+type countingObserver struct {
+	in          atomic.Int64
+	out         atomic.Int64
+	dropped     atomic.Int64
+	blocked     atomic.Int64
+	subscribers atomic.Int64
+}
+
+func (o *countingObserver) In()                     { o.in.Add(1) }
+func (o *countingObserver) Out()                    { o.out.Add(1) }
+func (o *countingObserver) Dropped()                { o.dropped.Add(1) }
+func (o *countingObserver) Blocked(d time.Duration) { o.blocked.Add(int64(d)) }
+func (o *countingObserver) Subscribers(n int)       { o.subscribers.Store(int64(n)) }
+
+// waitFor gives the operators some time to report the events, which happens
+// right after the values are sent.
+func waitFor(cond func() bool) {
+	for range 100 {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFanOutObserver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	h := FanOut(ctx, in)
+	defer h.Close()
+	obs := &countingObserver{}
+	h.SetObserver(obs)
+
+	full := h.AddWithPolicy(1, OverflowDropNewest)
+	out := h.Add(10)
+	if n := obs.subscribers.Load(); n != 2 {
+		t.Errorf("expected 2 subscribers, got %d", n)
+	}
+	h.Start()
+
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+	waitFor(func() bool { return obs.out.Load() == 4 })
+
+	if n := obs.in.Load(); n != 3 {
+		t.Errorf("expected 3 values in, got %d", n)
+	}
+	// 3 to out and 1 to full
+	if n := obs.out.Load(); n != 4 {
+		t.Errorf("expected 4 values out, got %d", n)
+	}
+	if n := obs.dropped.Load(); n != 2 {
+		t.Errorf("expected 2 dropped values, got %d", n)
+	}
+
+	h.Remove(full)
+	if n := obs.subscribers.Load(); n != 1 {
+		t.Errorf("expected 1 subscriber, got %d", n)
+	}
+}
+
+func TestFanInObserver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	ch1 := make(chan int, 1)
+	ch2 := make(chan int, 1)
+	ch1 <- 1
+	ch2 <- 2
+	h := FanIn(ctx, ch1, ch2)
+	defer h.Close()
+	obs := &countingObserver{}
+	h.SetObserver(obs)
+
+	out := h.Chan()
+	// let the readers wait on the output for a while
+	time.Sleep(10 * time.Millisecond)
+	<-out
+	<-out
+	waitFor(func() bool { return obs.out.Load() == 2 })
+
+	if n := obs.subscribers.Load(); n != 2 {
+		t.Errorf("expected 2 inputs, got %d", n)
+	}
+	if n := obs.in.Load(); n != 2 {
+		t.Errorf("expected 2 values in, got %d", n)
+	}
+	if n := obs.out.Load(); n != 2 {
+		t.Errorf("expected 2 values out, got %d", n)
+	}
+	if d := time.Duration(obs.blocked.Load()); d < 10*time.Millisecond {
+		t.Errorf("expected to be blocked for at least 10ms, got %s", d)
+	}
+
+	close(ch1)
+	for range 5 {
+		if obs.subscribers.Load() == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected 1 input, got %d", obs.subscribers.Load())
+}
+
+func TestDebounceAllObserved(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int, 3)
+	in <- 1
+	in <- 2
+	in <- 3
+	obs := &countingObserver{}
+
+	values := <-DebounceAllObserved(ctx, in, 10*time.Millisecond, obs)
+	if len(values) != 3 {
+		t.Errorf("expected 3 values, got %d", len(values))
+	}
+	waitFor(func() bool { return obs.out.Load() == 1 })
+	if n := obs.in.Load(); n != 3 {
+		t.Errorf("expected 3 values in, got %d", n)
+	}
+	if n := obs.out.Load(); n != 1 {
+		t.Errorf("expected 1 slice out, got %d", n)
+	}
+}
+
+func TestExpvarObserver(t *testing.T) {
+	// expvar names must be unique for the whole process
+	name := "mychannel_test_" + myrand.AlphaNumeric(8)
+	obs := NewExpvarObserver(name)
+	obs.In()
+	obs.In()
+	obs.Out()
+	obs.Dropped()
+	obs.Blocked(time.Second)
+	obs.Subscribers(3)
+
+	m := expvar.Get(name).(*expvar.Map)
+	expected := map[string]int64{
+		"in":          2,
+		"out":         1,
+		"dropped":     1,
+		"blocked_ns":  int64(time.Second),
+		"subscribers": 3,
+	}
+	for k, exp := range expected {
+		if v := m.Get(k).(*expvar.Int).Value(); v != exp {
+			t.Errorf("expected %s to be %d, got %d", k, exp, v)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+// Pipeline is a chain of stages connected with channels. Every stage runs in
+// its own goroutine. The first error returned by any stage cancels all the
+// others and is returned by Wait.
+// Go doesn't allow methods with their own type parameters, so stages which
+// change the type of the values are functions: PipeMap, PipeBatch and
+// PipeThrough.
+type Pipeline[T any] struct {
+	// g is shared between all stages of one pipeline.
+	g  *group
+	ch <-chan T
+}
+
+// Pipe starts a new pipeline reading from the source channel.
+func Pipe[T any](ctx context.Context, src <-chan T) *Pipeline[T] {
+	return &Pipeline[T]{g: newGroup(ctx), ch: src}
+}
+
+// PipeMap adds a stage which transforms every value with fn.
+func PipeMap[T any, R any](p *Pipeline[T], fn func(context.Context, T) (R, error)) *Pipeline[R] {
+	out := make(chan R)
+	g := p.g
+	g.spawn(func() error {
+		defer close(out)
+		for {
+			v, ok, ctxAlive := ReadOne(g.ctx, p.ch)
+			if !ok || !ctxAlive {
+				return nil
+			}
+			r, err := fn(g.ctx, v)
+			if err != nil {
+				return err
+			}
+			if !WriteOne(g.ctx, out, r) {
+				return nil
+			}
+		}
+	})
+	return &Pipeline[R]{g: g, ch: out}
+}
+
+// PipeBatch adds a stage which collects values into slices, see Batch.
+func PipeBatch[T any](p *Pipeline[T], maxSize int, maxWait time.Duration) *Pipeline[[]T] {
+	return PipeThrough(p, func(ctx context.Context, in <-chan T) <-chan []T {
+		return Batch(ctx, in, maxSize, maxWait)
+	})
+}
+
+// PipeThrough adds any channel operator as a stage, e.g. DebounceLast or
+// Throttle. The operator must close its output channel when the input
+// channel is closed or the context is cancelled.
+func PipeThrough[T any, R any](p *Pipeline[T], stage func(context.Context, <-chan T) <-chan R) *Pipeline[R] {
+	g := p.g
+	in := stage(g.ctx, p.ch)
+	// the operator runs in its own goroutine which Wait doesn't know about,
+	// forwarding its output keeps the pipeline alive until it's done
+	out := make(chan R)
+	g.spawn(func() error {
+		defer close(out)
+		for {
+			v, ok, ctxAlive := ReadOne(g.ctx, in)
+			if !ok || !ctxAlive {
+				return nil
+			}
+			if !WriteOne(g.ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return &Pipeline[R]{g: g, ch: out}
+}
+
+// Map adds a stage which transforms every value with fn. Use PipeMap to
+// change the type of the values.
+func (p *Pipeline[T]) Map(fn func(context.Context, T) (T, error)) *Pipeline[T] {
+	return PipeMap(p, fn)
+}
+
+// Filter adds a stage which only lets through the values for which fn
+// returns true.
+func (p *Pipeline[T]) Filter(fn func(T) bool) *Pipeline[T] {
+	out := make(chan T)
+	g := p.g
+	g.spawn(func() error {
+		defer close(out)
+		for {
+			v, ok, ctxAlive := ReadOne(g.ctx, p.ch)
+			if !ok || !ctxAlive {
+				return nil
+			}
+			if !fn(v) {
+				continue
+			}
+			if !WriteOne(g.ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return &Pipeline[T]{g: g, ch: out}
+}
+
+// Through adds any channel operator which doesn't change the type of the
+// values as a stage, see PipeThrough.
+func (p *Pipeline[T]) Through(stage func(context.Context, <-chan T) <-chan T) *Pipeline[T] {
+	return PipeThrough(p, stage)
+}
+
+// Sink adds the final stage which calls fn for every value. The returned
+// pipeline has no output channel, use Wait to wait for it to finish.
+func (p *Pipeline[T]) Sink(fn func(context.Context, T) error) *Pipeline[T] {
+	g := p.g
+	g.spawn(func() error {
+		for {
+			v, ok, ctxAlive := ReadOne(g.ctx, p.ch)
+			if !ok || !ctxAlive {
+				return nil
+			}
+			if err := fn(g.ctx, v); err != nil {
+				return err
+			}
+		}
+	})
+	return &Pipeline[T]{g: g}
+}
+
+// Chan returns the output channel of the last stage. It's nil after Sink.
+func (p *Pipeline[T]) Chan() <-chan T {
+	return p.ch
+}
+
+// Wait waits for all stages to finish and returns the first error. If the
+// context given to Pipe was cancelled before the stages finished, its error
+// is returned.
+func (p *Pipeline[T]) Wait() error {
+	return p.g.wait()
+}
@@ -0,0 +1,143 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	src := make(chan int)
+	go func() {
+		defer close(src)
+		for i := 1; i <= 10; i++ {
+			src <- i
+		}
+	}()
+
+	even := Pipe(ctx, src).
+		Filter(func(v int) bool { return v%2 == 0 }).
+		Map(func(_ context.Context, v int) (int, error) { return v * 10, nil })
+	strs := PipeMap(even, func(_ context.Context, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	})
+
+	var got [][]string
+	err := PipeBatch(strs, 2, time.Second).
+		Sink(func(_ context.Context, v []string) error {
+			got = append(got, v)
+			return nil
+		}).
+		Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{{"20", "40"}, {"60", "80"}, {"100"}}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if len(got[i]) != len(expected[i]) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		for j := range expected[i] {
+			if got[i][j] != expected[i][j] {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		}
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	// the source never closes, so only the error can stop the pipeline
+	src := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			if !WriteOne(ctx, src, i) {
+				return
+			}
+		}
+	}()
+
+	errBoom := errors.New("boom")
+	err := Pipe(ctx, src).
+		Map(func(_ context.Context, v int) (int, error) {
+			if v == 5 {
+				return 0, errBoom
+			}
+			return v, nil
+		}).
+		Through(func(ctx context.Context, in <-chan int) <-chan int {
+			return Throttle(ctx, in, time.Millisecond)
+		}).
+		Sink(func(context.Context, int) error { return nil }).
+		Wait()
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected %v, got %v", errBoom, err)
+	}
+}
+
+func TestPipelineContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	p := Pipe(ctx, src).Sink(func(context.Context, int) error { return nil })
+	cancel()
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestPipelineEndingInOperator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	src := make(chan int)
+	go func() {
+		defer close(src)
+		for i := 1; i <= 5; i++ {
+			src <- i
+		}
+	}()
+
+	p := PipeBatch(Pipe(ctx, src), 2, time.Second)
+	// Wait runs while the values are still flowing
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Wait()
+	}()
+
+	var got []int
+	for batch := range p.Chan() {
+		got = append(got, batch...)
+	}
+	if len(got) != 5 {
+		t.Errorf("expected 5 values, got %v", got)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPipelineFinishedBeforeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	close(src)
+	p := Pipe(ctx, src).
+		Through(func(ctx context.Context, in <-chan int) <-chan int {
+			return Throttle(ctx, in, time.Millisecond)
+		})
+	for range p.Chan() {
+	}
+	// give the last stage time to exit before the context is cancelled
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := p.Wait(); err != nil {
+		t.Fatalf("expected no error for a finished pipeline, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package mychannel
+
+import "context"
+
+// PoolHolder processes values from an input channel with a fixed number of
+// workers. Results are available on Chan and the first error returned by the
+// worker function is available through Wait.
+type PoolHolder[T any, R any] struct {
+	g *group
+
+	out  chan R
+	done chan struct{}
+}
+
+type poolJob[T any, R any] struct {
+	val T
+	// res is only used when the order of the results must be preserved.
+	res chan R
+}
+
+// ParallelMap starts a pool of workers which read from the input channel,
+// call fn for every value and send the result to the output channel. The
+// order of the results is not preserved. Use ParallelMapOrdered if you need
+// the results in the same order as the input.
+// The first error returned by fn cancels the whole pool. The output channel
+// is closed when the input channel is closed and all values are processed,
+// when fn fails or when the context is cancelled.
+func ParallelMap[T any, R any](
+	ctx context.Context,
+	in <-chan T,
+	workers int,
+	fn func(context.Context, T) (R, error),
+) *PoolHolder[T, R] {
+	return startPool(ctx, in, workers, fn, false)
+}
+
+// ParallelMapOrdered is the same as ParallelMap, but the results are sent to
+// the output channel in the same order as the values were read from the input
+// channel. A slow value holds back the results which came after it, so at
+// most 2*workers values are being processed or waiting at any time.
+func ParallelMapOrdered[T any, R any](
+	ctx context.Context,
+	in <-chan T,
+	workers int,
+	fn func(context.Context, T) (R, error),
+) *PoolHolder[T, R] {
+	return startPool(ctx, in, workers, fn, true)
+}
+
+func startPool[T any, R any](
+	ctx context.Context,
+	in <-chan T,
+	workers int,
+	fn func(context.Context, T) (R, error),
+	ordered bool,
+) *PoolHolder[T, R] {
+	if workers <= 0 {
+		panic("number of workers must be positive")
+	}
+	g := newGroup(ctx)
+	p := &PoolHolder[T, R]{
+		g:    g,
+		out:  make(chan R),
+		done: make(chan struct{}),
+	}
+
+	jobs := make(chan poolJob[T, R])
+	// pending keeps the jobs in the input order so that the results can be
+	// sent out in the same order.
+	var pending chan poolJob[T, R]
+	if ordered {
+		pending = make(chan poolJob[T, R], workers)
+	}
+
+	g.spawn(func() error {
+		defer close(jobs)
+		if pending != nil {
+			defer close(pending)
+		}
+		for {
+			v, ok, ctxAlive := ReadOne(g.ctx, in)
+			if !ok || !ctxAlive {
+				return nil
+			}
+			job := poolJob[T, R]{val: v}
+			if ordered {
+				job.res = make(chan R, 1)
+				if !WriteOne(g.ctx, pending, job) {
+					return nil
+				}
+			}
+			if !WriteOne(g.ctx, jobs, job) {
+				return nil
+			}
+		}
+	})
+
+	for range workers {
+		g.spawn(func() error {
+			for {
+				job, ok, ctxAlive := ReadOne(g.ctx, jobs)
+				if !ok || !ctxAlive {
+					return nil
+				}
+				res, err := fn(g.ctx, job.val)
+				if err != nil {
+					return err
+				}
+				if ordered {
+					// buffered with the size of 1, never blocks
+					job.res <- res
+					continue
+				}
+				if !WriteOne(g.ctx, p.out, res) {
+					return nil
+				}
+			}
+		})
+	}
+
+	if ordered {
+		g.spawn(func() error {
+			for {
+				job, ok, ctxAlive := ReadOne(g.ctx, pending)
+				if !ok || !ctxAlive {
+					return nil
+				}
+				res, ok, ctxAlive := ReadOne(g.ctx, job.res)
+				if !ok || !ctxAlive {
+					return nil
+				}
+				if !WriteOne(g.ctx, p.out, res) {
+					return nil
+				}
+			}
+		})
+	}
+
+	go func() {
+		g.wait()
+		close(p.out)
+		close(p.done)
+	}()
+
+	return p
+}
+
+// Chan returns the channel with the results. It's closed once the pool is
+// done.
+func (p *PoolHolder[T, R]) Chan() <-chan R {
+	return p.out
+}
+
+// Wait blocks until all workers are done and returns the first error returned
+// by the worker function, or the context error if the context was cancelled.
+// Results must be read from Chan, otherwise Wait blocks forever.
+func (p *PoolHolder[T, R]) Wait() error {
+	<-p.done
+	return p.g.err
+}
+
+// Cancel stops the pool without waiting for the remaining values.
+func (p *PoolHolder[T, R]) Cancel() {
+	p.g.cancel()
+}
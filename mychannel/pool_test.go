@@ -0,0 +1,123 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParallelMap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 100; i++ {
+			in <- i
+		}
+	}()
+
+	p := ParallelMap(ctx, in, 4, func(_ context.Context, v int) (int, error) {
+		return v * 2, nil
+	})
+
+	var got []int
+	for v := range p.Chan() {
+		got = append(got, v)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 100 {
+		t.Fatalf("expected 100 results, got %d", len(got))
+	}
+	sort.Ints(got)
+	for i, v := range got {
+		if v != (i+1)*2 {
+			t.Errorf("expected %d, got %d", (i+1)*2, v)
+		}
+	}
+}
+
+func TestParallelMapOrdered(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < 20; i++ {
+			in <- i
+		}
+	}()
+
+	p := ParallelMapOrdered(ctx, in, 4, func(_ context.Context, v int) (int, error) {
+		// earlier values are slower so that they finish out of order
+		time.Sleep(time.Duration(20-v) * time.Millisecond)
+		return v, nil
+	})
+
+	expected := 0
+	for v := range p.Chan() {
+		if v != expected {
+			t.Errorf("expected %d, got %d", expected, v)
+		}
+		expected++
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected != 20 {
+		t.Errorf("expected 20 results, got %d", expected)
+	}
+}
+
+func TestParallelMapError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < 100; i++ {
+			if !WriteOne(ctx, in, i) {
+				return
+			}
+		}
+	}()
+
+	errBoom := errors.New("boom")
+	p := ParallelMap(ctx, in, 3, func(_ context.Context, v int) (int, error) {
+		if v == 10 {
+			return 0, errBoom
+		}
+		return v, nil
+	})
+
+	for range p.Chan() {
+	}
+	if err := p.Wait(); !errors.Is(err, errBoom) {
+		t.Fatalf("expected %v, got %v", errBoom, err)
+	}
+}
+
+func TestParallelMapContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan int)
+	p := ParallelMapOrdered(ctx, in, 2, func(_ context.Context, v int) (int, error) {
+		return v, nil
+	})
+	cancel()
+
+	_, ok := <-p.Chan()
+	if ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
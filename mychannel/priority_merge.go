@@ -0,0 +1,137 @@
+package mychannel
+
+import (
+	"context"
+	"reflect"
+)
+
+// PriorityMerge merges the channels into one. When several channels have a
+// value ready, the value from the channel which comes first in the arguments
+// is sent first. A busy high-priority channel can starve the others, use
+// WeightedPriorityMerge to prevent that.
+func PriorityMerge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	return WeightedPriorityMerge(ctx, nil, chans...)
+}
+
+// WeightedPriorityMerge is PriorityMerge with starvation protection. A channel
+// can send at most weights[i] values in a row while lower-priority channels
+// are waiting, after which they get their turn. A weight of 0 or less, or a
+// missing weight, means no limit for that channel, but when it gets its turn
+// because a higher-priority channel used up its weight, it sends a single
+// value and yields back.
+// The output channel is closed when all input channels are closed or the
+// context is cancelled.
+func WeightedPriorityMerge[T any](ctx context.Context, weights []int, chans ...<-chan T) <-chan T {
+	ret := make(chan T)
+
+	go func() {
+		defer close(ret)
+
+		open := make([]<-chan T, len(chans))
+		copy(open, chans)
+		remaining := len(open)
+
+		limit := func(i int) int {
+			if i < len(weights) {
+				return weights[i]
+			}
+			return 0
+		}
+		credits := make([]int, len(open))
+		refill := func() {
+			for i := range credits {
+				credits[i] = limit(i)
+			}
+		}
+		refill()
+
+		// cases for the blocking select, the last one is the context
+		cases := make([]reflect.SelectCase, len(open)+1)
+		for i, ch := range open {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
+		}
+		cases[len(open)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+
+		markClosed := func(i int) {
+			open[i] = nil
+			// receiving from a nil channel blocks forever, so the
+			// closed channel is never selected again
+			cases[i].Chan = reflect.ValueOf((<-chan T)(nil))
+			remaining--
+		}
+
+		// tryRecv returns the value from the highest priority channel which
+		// has a value ready. If withCredits is true, channels which used up
+		// their credits are skipped.
+		tryRecv := func(withCredits bool) (v T, idx int, got bool) {
+			for i, ch := range open {
+				if ch == nil {
+					continue
+				}
+				if withCredits && limit(i) > 0 && credits[i] <= 0 {
+					continue
+				}
+				select {
+				case v, ok := <-ch:
+					if !ok {
+						markClosed(i)
+						continue
+					}
+					return v, i, true
+				default:
+				}
+			}
+			return v, -1, false
+		}
+
+		// waiting returns true if a higher-priority channel than idx used up
+		// its credits
+		waiting := func(idx int) bool {
+			for i := range idx {
+				if open[i] != nil && limit(i) > 0 && credits[i] <= 0 {
+					return true
+				}
+			}
+			return false
+		}
+
+		for remaining > 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			v, idx, got := tryRecv(true)
+			if !got {
+				// the channels with credits left have nothing to send,
+				// so everyone gets a new turn
+				refill()
+				v, idx, got = tryRecv(false)
+			}
+			if !got {
+				if remaining == 0 {
+					return
+				}
+				chosen, rv, ok := reflect.Select(cases)
+				if chosen == len(open) {
+					return
+				}
+				if !ok {
+					markClosed(chosen)
+					continue
+				}
+				// the comma-ok form handles nil interface values
+				v, _ = rv.Interface().(T)
+				idx = chosen
+			}
+			if limit(idx) <= 0 && waiting(idx) {
+				// an unlimited channel only gets a single turn
+				refill()
+			}
+			credits[idx]--
+			if !WriteOne(ctx, ret, v) {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
@@ -0,0 +1,118 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPriorityMerge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	control := make(chan string, 3)
+	data := make(chan string, 3)
+	for range 3 {
+		data <- "data"
+	}
+	for range 3 {
+		control <- "control"
+	}
+	close(control)
+	close(data)
+
+	var got []string
+	for v := range PriorityMerge(ctx, control, data) {
+		got = append(got, v)
+	}
+	expected := []string{"control", "control", "control", "data", "data", "data"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestWeightedPriorityMerge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	high := make(chan int, 6)
+	low := make(chan int, 3)
+	for range 6 {
+		high <- 1
+	}
+	for range 3 {
+		low <- 2
+	}
+	close(high)
+	close(low)
+
+	var got []int
+	for v := range WeightedPriorityMerge(ctx, []int{2, 1}, high, low) {
+		got = append(got, v)
+	}
+	expected := []int{1, 1, 2, 1, 1, 2, 1, 1, 2}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestWeightedPriorityMergePartialWeights(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	high := make(chan int, 6)
+	low := make(chan int, 6)
+	for range 6 {
+		high <- 1
+		low <- 2
+	}
+	close(high)
+	close(low)
+
+	// the low-priority channel has no weight, but it must not take over
+	// once the high-priority one used up its weight
+	var got []int
+	for v := range WeightedPriorityMerge(ctx, []int{2}, high, low) {
+		got = append(got, v)
+	}
+	expected := []int{1, 1, 2, 1, 1, 2, 1, 1, 2, 2, 2, 2}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestPriorityMergeBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	high := make(chan int)
+	low := make(chan int)
+	out := PriorityMerge(ctx, high, low)
+
+	go func() {
+		low <- 2
+	}()
+	if v := <-out; v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+
+	cancel()
+	if _, ok := <-out; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
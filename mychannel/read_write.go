@@ -1,6 +1,9 @@
 package mychannel
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ReadOne reads one value from the channel while respecting the context.
 func ReadOne[T any](ctx context.Context, in <-chan T) (val T, chOK bool, ctxAlive bool) {
@@ -39,3 +42,91 @@ func ReadWhile[T any](ctx context.Context, in <-chan T) <-chan T {
 
 	return out
 }
+
+// ReadN reads n values from the channel while respecting the context. If the
+// channel is closed or the context is cancelled first, the values read so far
+// are returned. It panics if n is negative.
+func ReadN[T any](ctx context.Context, in <-chan T, n int) (vals []T, chOK bool, ctxAlive bool) {
+	if n < 0 {
+		panic("n must be non-negative")
+	}
+	vals = make([]T, 0, n)
+	for len(vals) < n {
+		v, ok, alive := ReadOne(ctx, in)
+		if !ok || !alive {
+			return vals, ok, alive
+		}
+		vals = append(vals, v)
+	}
+	return vals, true, true
+}
+
+// ReadUntil reads values from the channel until the deadline. If the channel
+// is closed or the context is cancelled first, the values read so far are
+// returned. Reaching the deadline is not an error, chOK and ctxAlive are both
+// true.
+func ReadUntil[T any](ctx context.Context, in <-chan T, deadline time.Time) (vals []T, chOK bool, ctxAlive bool) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return vals, false, true
+			}
+			vals = append(vals, v)
+		case <-timer.C:
+			return vals, true, true
+		case <-ctx.Done():
+			return vals, false, false
+		}
+	}
+}
+
+// ReadAll reads values from the channel until it's closed. If the context is
+// cancelled first, the values read so far are returned.
+func ReadAll[T any](ctx context.Context, in <-chan T) (vals []T, ctxAlive bool) {
+	for {
+		v, ok, alive := ReadOne(ctx, in)
+		if !alive {
+			return vals, false
+		}
+		if !ok {
+			return vals, true
+		}
+		vals = append(vals, v)
+	}
+}
+
+// TryRead reads one value from the channel without blocking. ready is false
+// if there was nothing to read.
+func TryRead[T any](in <-chan T) (val T, chOK bool, ready bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok, true
+	default:
+		return val, true, false
+	}
+}
+
+// TryWrite writes one value to the channel without blocking. It returns false
+// if the channel is full.
+func TryWrite[T any](out chan<- T, val T) (written bool) {
+	select {
+	case out <- val:
+		return true
+	default:
+		return false
+	}
+}
+
+// WriteAll writes all values to the channel while respecting the context. It
+// returns the number of values written.
+func WriteAll[T any](ctx context.Context, out chan<- T, vals []T) (written int, ctxAlive bool) {
+	for i, v := range vals {
+		if !WriteOne(ctx, out, v) {
+			return i, false
+		}
+	}
+	return len(vals), true
+}
@@ -113,15 +113,15 @@ func TestWriteOne(t *testing.T) {
 
 func TestReadWhile(t *testing.T) {
 	ch := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		defer close(ch)
-		ch <- 1
-		ch <- 1
-		ch <- 1
-		ch <- 1
+		WriteOne(ctx, ch, 1)
+		WriteOne(ctx, ch, 1)
+		WriteOne(ctx, ch, 1)
+		WriteOne(ctx, ch, 1)
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
 	out := ReadWhile(ctx, ch)
 
 	_, ok := <-out
@@ -142,3 +142,94 @@ func TestReadWhile(t *testing.T) {
 
 	t.Fatal("channel should be closed")
 }
+
+func TestReadN(t *testing.T) {
+	ch := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	ctx := context.Background()
+
+	vals, chok, ctxAlive := ReadN(ctx, ch, 3)
+	if len(vals) != 3 || !chok || !ctxAlive {
+		t.Errorf("expected 3 values, got %v %t %t", vals, chok, ctxAlive)
+	}
+	vals, chok, ctxAlive = ReadN(ctx, ch, 3)
+	if len(vals) != 2 || chok || !ctxAlive {
+		t.Errorf("expected 2 values and a closed channel, got %v %t %t", vals, chok, ctxAlive)
+	}
+
+	defer func() {
+		if r := recover(); r != "n must be non-negative" {
+			t.Errorf("expected a panic for a negative n, got %v", r)
+		}
+	}()
+	ReadN(ctx, ch, -1)
+}
+
+func TestReadUntil(t *testing.T) {
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	ctx, cancel := context.WithCancel(context.Background())
+
+	vals, chok, ctxAlive := ReadUntil(ctx, ch, time.Now().Add(10*time.Millisecond))
+	if len(vals) != 2 || !chok || !ctxAlive {
+		t.Errorf("expected 2 values, got %v %t %t", vals, chok, ctxAlive)
+	}
+
+	cancel()
+	vals, chok, ctxAlive = ReadUntil(ctx, ch, time.Now().Add(time.Hour))
+	if len(vals) != 0 || chok || ctxAlive {
+		t.Errorf("expected a cancelled context, got %v %t %t", vals, chok, ctxAlive)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; i < 10; i++ {
+			ch <- i
+		}
+	}()
+
+	vals, ctxAlive := ReadAll(context.Background(), ch)
+	if len(vals) != 10 || !ctxAlive {
+		t.Errorf("expected 10 values, got %v %t", vals, ctxAlive)
+	}
+}
+
+func TestTryReadAndTryWrite(t *testing.T) {
+	ch := make(chan int, 1)
+
+	if _, _, ready := TryRead(ch); ready {
+		t.Errorf("expected nothing to read")
+	}
+	if !TryWrite(ch, 1) {
+		t.Errorf("expected the value to be written")
+	}
+	if TryWrite(ch, 2) {
+		t.Errorf("expected the channel to be full")
+	}
+	val, chok, ready := TryRead(ch)
+	if val != 1 || !chok || !ready {
+		t.Errorf("expected 1, got %d %t %t", val, chok, ready)
+	}
+	close(ch)
+	if _, chok, ready := TryRead(ch); chok || !ready {
+		t.Errorf("expected a closed channel, got %t %t", chok, ready)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	ch := make(chan int, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	written, ctxAlive := WriteAll(ctx, ch, []int{1, 2, 3})
+	if written != 2 || ctxAlive {
+		t.Errorf("expected 2 values written before the timeout, got %d %t", written, ctxAlive)
+	}
+}
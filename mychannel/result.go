@@ -0,0 +1,133 @@
+package mychannel
+
+import (
+	"context"
+	"fmt"
+)
+
+// Result carries either a value or an error through a channel.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// OK returns a successful result.
+func OK[T any](v T) Result[T] {
+	return Result[T]{Value: v}
+}
+
+// Fail returns a failed result.
+func Fail[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// Unwrap returns the value and the error of the result.
+func (r Result[T]) Unwrap() (T, error) {
+	return r.Value, r.Err
+}
+
+// SourceError is returned by ResultFanInHolder and tells which of the input
+// channels failed.
+type SourceError struct {
+	// Source is the index of the input channel as given to FanInResults.
+	Source int
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("source %d: %v", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorPolicy decides what happens with the other inputs when one of them
+// fails.
+type ErrorPolicy int
+
+const (
+	// StopOnError cancels all inputs on the first error.
+	StopOnError ErrorPolicy = iota
+	// ContinueOnError skips failed results and keeps reading. Only the first
+	// error is reported.
+	ContinueOnError
+)
+
+// ResultFanInHolder merges channels of results into a single channel of
+// values. Errors are not sent to the output channel, the first one is
+// returned by Wait instead.
+type ResultFanInHolder[T any] struct {
+	g      *group
+	policy ErrorPolicy
+
+	out  chan T
+	done chan struct{}
+}
+
+// FanInResults merges the given channels of results. The output channel is
+// closed when all inputs are closed, when the context is cancelled or, with
+// StopOnError, on the first error.
+func FanInResults[T any](ctx context.Context, policy ErrorPolicy, chans ...<-chan Result[T]) *ResultFanInHolder[T] {
+	g := newGroup(ctx)
+	g.keepGoing = policy == ContinueOnError
+	h := &ResultFanInHolder[T]{
+		g:      g,
+		policy: policy,
+		out:    make(chan T),
+		done:   make(chan struct{}),
+	}
+
+	for i, ch := range chans {
+		g.spawn(func() error {
+			return h.read(i, ch)
+		})
+	}
+
+	go func() {
+		g.wait()
+		close(h.out)
+		close(h.done)
+	}()
+
+	return h
+}
+
+func (h *ResultFanInHolder[T]) read(source int, ch <-chan Result[T]) error {
+	for {
+		res, ok, ctxAlive := ReadOne(h.g.ctx, ch)
+		if !ok || !ctxAlive {
+			return nil
+		}
+		if res.Err != nil {
+			err := &SourceError{Source: source, Err: res.Err}
+			if h.policy == StopOnError {
+				return err
+			}
+			h.g.fail(err)
+			continue
+		}
+		if !WriteOne(h.g.ctx, h.out, res.Value) {
+			return nil
+		}
+	}
+}
+
+// Context returns a context which is cancelled when the holder stops. Pass it
+// to the producers so that they stop as well.
+func (h *ResultFanInHolder[T]) Context() context.Context {
+	return h.g.ctx
+}
+
+// Chan returns the channel with the values from all inputs.
+func (h *ResultFanInHolder[T]) Chan() <-chan T {
+	return h.out
+}
+
+// Wait waits until the output channel is closed and returns the first error.
+// Errors from the inputs are wrapped in *SourceError. The values must be read
+// from Chan, otherwise Wait blocks forever.
+func (h *ResultFanInHolder[T]) Wait() error {
+	<-h.done
+	return h.g.err
+}
@@ -0,0 +1,94 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestFanInResults(t *testing.T) {
+	errBoom := errors.New("boom")
+	tt := []struct {
+		name     string
+		policy   ErrorPolicy
+		expected []int
+	}{
+		{
+			name:     "continue on error",
+			policy:   ContinueOnError,
+			expected: []int{1, 2, 3, 4},
+		},
+		{
+			name:     "stop on error",
+			policy:   StopOnError,
+			expected: []int{1, 2},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+
+			ok := make(chan Result[int])
+			failing := make(chan Result[int])
+			h := FanInResults(ctx, tc.policy, ok, failing)
+
+			go func() {
+				defer close(ok)
+				WriteOne(h.Context(), ok, OK(1))
+				WriteOne(h.Context(), ok, OK(2))
+				// give the failing source time to report the error
+				time.Sleep(20 * time.Millisecond)
+				WriteOne(h.Context(), ok, OK(3))
+			}()
+			go func() {
+				defer close(failing)
+				time.Sleep(10 * time.Millisecond)
+				WriteOne(h.Context(), failing, Fail[int](errBoom))
+				WriteOne(h.Context(), failing, OK(4))
+			}()
+
+			var got []int
+			for v := range h.Chan() {
+				got = append(got, v)
+			}
+			sort.Ints(got)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("expected %v, got %v", tc.expected, got)
+				}
+			}
+
+			err := h.Wait()
+			if !errors.Is(err, errBoom) {
+				t.Fatalf("expected %v, got %v", errBoom, err)
+			}
+			var serr *SourceError
+			if !errors.As(err, &serr) {
+				t.Fatalf("expected *SourceError, got %T", err)
+			}
+			if serr.Source != 1 {
+				t.Errorf("expected source 1, got %d", serr.Source)
+			}
+		})
+	}
+}
+
+func TestResultUnwrap(t *testing.T) {
+	v, err := OK(5).Unwrap()
+	if v != 5 || err != nil {
+		t.Errorf("expected 5 and no error, got %d and %v", v, err)
+	}
+	errBoom := errors.New("boom")
+	_, err = Fail[int](errBoom).Unwrap()
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected %v, got %v", errBoom, err)
+	}
+}
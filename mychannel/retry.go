@@ -0,0 +1,90 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times fn is called for a value before
+	// giving up, including the first call.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// Multiplier greater than 1 makes every delay Multiplier times longer
+	// than the previous one.
+	Multiplier float64
+	// MaxDelay caps the delay growing because of the Multiplier.
+	MaxDelay time.Duration
+	// Jitter adds a random part of up to Jitter*delay to every delay.
+	Jitter float64
+}
+
+// DeadLetter is a value for which all attempts failed.
+type DeadLetter[T any] struct {
+	Value T
+	// Err is the error of the last attempt.
+	Err      error
+	Attempts int
+}
+
+// Retry calls fn for every value from the channel, one at a time, retrying it
+// with a backoff until it succeeds or the attempts run out. The values which
+// exhausted their attempts are sent to the returned channel, which must be
+// read, otherwise the processing stops. It's closed when the input channel is
+// closed or the context is cancelled.
+func Retry[T any](ctx context.Context, in <-chan T, fn func(context.Context, T) error, policy RetryPolicy) <-chan DeadLetter[T] {
+	if policy.MaxAttempts < 1 {
+		panic("max attempts must be at least 1")
+	}
+	ret := make(chan DeadLetter[T])
+
+	go func() {
+		defer close(ret)
+		for {
+			v, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			dl, ctxAlive := retry(ctx, v, fn, policy)
+			if !ctxAlive {
+				return
+			}
+			if dl == nil {
+				continue
+			}
+			if !WriteOne(ctx, ret, *dl) {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
+
+// retry handles a single value. It returns nil if fn succeeded.
+func retry[T any](ctx context.Context, v T, fn func(context.Context, T) error, policy RetryPolicy) (dl *DeadLetter[T], ctxAlive bool) {
+	b := backoff{
+		current:    policy.InitialDelay,
+		max:        policy.MaxDelay,
+		multiplier: policy.Multiplier,
+		jitter:     policy.Jitter,
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx, v)
+		if err == nil {
+			return nil, true
+		}
+		if attempt == policy.MaxAttempts {
+			return &DeadLetter[T]{Value: v, Err: err, Attempts: attempt}, true
+		}
+		timer := time.NewTimer(b.next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false
+		case <-timer.C:
+		}
+	}
+}
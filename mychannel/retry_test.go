@@ -0,0 +1,107 @@
+package mychannel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	errBoom := errors.New("boom")
+	var mu sync.Mutex
+	attempts := make(map[int]int)
+	// odd values succeed on the 2nd attempt, even values never succeed
+	fn := func(_ context.Context, v int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[v]++
+		if v%2 == 1 && attempts[v] == 2 {
+			return nil
+		}
+		return errBoom
+	}
+
+	in := make(chan int, 4)
+	for i := 1; i <= 4; i++ {
+		in <- i
+	}
+	close(in)
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, Multiplier: 2, Jitter: 0.1}
+	var dead []DeadLetter[int]
+	for dl := range Retry(ctx, in, fn, policy) {
+		dead = append(dead, dl)
+	}
+
+	if len(dead) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d", len(dead))
+	}
+	for i, exp := range []int{2, 4} {
+		if dead[i].Value != exp {
+			t.Errorf("expected %d, got %d", exp, dead[i].Value)
+		}
+		if dead[i].Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", dead[i].Attempts)
+		}
+		if !errors.Is(dead[i].Err, errBoom) {
+			t.Errorf("expected %v, got %v", errBoom, dead[i].Err)
+		}
+	}
+	for v, exp := range map[int]int{1: 2, 2: 3, 3: 2, 4: 3} {
+		if attempts[v] != exp {
+			t.Errorf("expected %d attempts for %d, got %d", exp, v, attempts[v])
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	var calls []time.Time
+	fn := func(context.Context, int) error {
+		calls = append(calls, time.Now())
+		return errors.New("boom")
+	}
+	in := make(chan int, 1)
+	in <- 1
+	close(in)
+
+	policy := RetryPolicy{MaxAttempts: 4, InitialDelay: 10 * time.Millisecond, Multiplier: 2, MaxDelay: 15 * time.Millisecond}
+	for range Retry(ctx, in, fn, policy) {
+	}
+
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %d", len(calls))
+	}
+	// 10ms, then 20ms capped to 15ms twice
+	for i, minDelay := range []time.Duration{10, 15, 15} {
+		if d := calls[i+1].Sub(calls[i]); d < minDelay*time.Millisecond {
+			t.Errorf("expected delay %d to be at least %dms, got %s", i, minDelay, d)
+		}
+	}
+}
+
+func TestRetryCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int, 1)
+	in <- 1
+
+	called := make(chan struct{}, 1)
+	fn := func(context.Context, int) error {
+		called <- struct{}{}
+		return errors.New("boom")
+	}
+	out := Retry(ctx, in, fn, RetryPolicy{MaxAttempts: 10, InitialDelay: time.Hour})
+	<-called
+	cancel()
+
+	if _, ok := <-out; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
@@ -0,0 +1,57 @@
+package mychannel
+
+// ringMinSize is the smallest capacity the ring buffer shrinks to.
+const ringMinSize = 16
+
+// ring is a FIFO queue backed by a circular buffer which grows when full and
+// shrinks when mostly empty.
+type ring[T any] struct {
+	buf  []T
+	head int
+	size int
+}
+
+func (r *ring[T]) len() int {
+	return r.size
+}
+
+func (r *ring[T]) push(v T) {
+	if r.size == len(r.buf) {
+		r.resize(max(2*len(r.buf), ringMinSize))
+	}
+	r.buf[(r.head+r.size)%len(r.buf)] = v
+	r.size++
+}
+
+func (r *ring[T]) peek() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	return r.buf[r.head], true
+}
+
+func (r *ring[T]) pop() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	v := r.buf[r.head]
+	// let the garbage collector take the value
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.size--
+	if len(r.buf) > ringMinSize && r.size < len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+	return v, true
+}
+
+func (r *ring[T]) resize(n int) {
+	buf := make([]T, n)
+	for i := range r.size {
+		buf[i] = r.buf[(r.head+i)%len(r.buf)]
+	}
+	r.buf = buf
+	r.head = 0
+}
@@ -0,0 +1,126 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+// Throttle sends the first value and then ignores all values for the given
+// interval (leading edge).
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	ret := make(chan T)
+
+	go func() {
+		defer close(ret)
+		var last time.Time
+		for {
+			v, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			now := time.Now()
+			if !last.IsZero() && now.Sub(last) < interval {
+				continue
+			}
+			last = now
+			if !WriteOne(ctx, ret, v) {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
+
+// ThrottleLast waits for the given interval after the first value and then
+// sends the last value received during that interval (trailing edge). If the
+// input channel is closed, the pending value is still sent.
+func ThrottleLast[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	ret := make(chan T)
+
+	go func() {
+		defer close(ret)
+		for {
+			last, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			timer := time.NewTimer(interval)
+			closed := false
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+					break loop
+				case v, ok := <-in:
+					if !ok {
+						closed = true
+						break loop
+					}
+					last = v
+				}
+			}
+			timer.Stop()
+			if !WriteOne(ctx, ret, last) || closed {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
+
+// RateLimit sends values at the rate of one value per interval, allowing
+// bursts of up to burst values (token bucket). Values are never dropped, the
+// reading from the input channel is delayed instead. To allow N values per
+// period, use period/N as the interval.
+func RateLimit[T any](ctx context.Context, in <-chan T, interval time.Duration, burst int) <-chan T {
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+	if burst <= 0 {
+		panic("burst must be positive")
+	}
+	ret := make(chan T)
+
+	go func() {
+		defer close(ret)
+		tokens := float64(burst)
+		last := time.Now()
+		refill := func() {
+			now := time.Now()
+			tokens += float64(now.Sub(last)) / float64(interval)
+			if tokens > float64(burst) {
+				tokens = float64(burst)
+			}
+			last = now
+		}
+		for {
+			v, ok, ctxAlive := ReadOne(ctx, in)
+			if !ok || !ctxAlive {
+				return
+			}
+			refill()
+			if tokens < 1 {
+				wait := time.Duration((1 - tokens) * float64(interval))
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				refill()
+			}
+			tokens--
+			if !WriteOne(ctx, ret, v) {
+				return
+			}
+		}
+	}()
+
+	return ret
+}
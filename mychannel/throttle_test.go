@@ -0,0 +1,101 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		in <- 1
+		in <- 2
+		in <- 3
+		time.Sleep(60 * time.Millisecond)
+		in <- 4
+		in <- 5
+	}()
+
+	var got []int
+	for v := range Throttle(ctx, in, 50*time.Millisecond) {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Errorf("expected [1 4], got %v", got)
+	}
+}
+
+func TestThrottleLast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		in <- 1
+		in <- 2
+		in <- 3
+		time.Sleep(60 * time.Millisecond)
+		in <- 4
+		in <- 5
+	}()
+
+	var got []int
+	for v := range ThrottleLast(ctx, in, 30*time.Millisecond) {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 5 {
+		t.Errorf("expected [3 5], got %v", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 0; i < 6; i++ {
+			in <- i
+		}
+	}()
+
+	interval := 20 * time.Millisecond
+	start := time.Now()
+	out := RateLimit(ctx, in, interval, 2)
+
+	cnt := 0
+	for v := range out {
+		if v != cnt {
+			t.Errorf("expected %d, got %d", cnt, v)
+		}
+		cnt++
+	}
+	if cnt != 6 {
+		t.Fatalf("expected 6 values, got %d", cnt)
+	}
+	// the first two values are the burst, the other four have to wait for
+	// the tokens
+	if elapsed := time.Since(start); elapsed < 4*interval {
+		t.Errorf("expected at least %s, took %s", 4*interval, elapsed)
+	}
+}
+
+func TestRateLimitContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	out := RateLimit(ctx, in, time.Hour, 1)
+	if v := <-out; v != 0 {
+		t.Errorf("expected 0, got %d", v)
+	}
+	cancel()
+	if _, ok := <-out; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
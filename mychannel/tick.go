@@ -0,0 +1,63 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+// TickOptions configures Tick. The zero value is a plain ticker.
+type TickOptions struct {
+	// Immediate sends the first tick right away instead of after the first
+	// interval.
+	Immediate bool
+	// Jitter adds a random part of up to Jitter*interval to every interval,
+	// e.g. 0.1 makes the intervals up to 10% longer. It keeps many pollers
+	// from firing at the same time.
+	Jitter float64
+	// Multiplier greater than 1 makes every interval Multiplier times longer
+	// than the previous one (exponential backoff).
+	Multiplier float64
+	// MaxInterval caps the interval growing because of the Multiplier.
+	MaxInterval time.Duration
+}
+
+// Tick sends the current time periodically. Unlike time.Ticker, the next
+// interval starts only after the tick was read, so slow readers never get a
+// burst of old ticks. The channel is closed when the context is cancelled, so
+// there is no ticker to stop.
+func Tick(ctx context.Context, interval time.Duration, opts TickOptions) <-chan time.Time {
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+	ret := make(chan time.Time)
+
+	go func() {
+		defer close(ret)
+		b := backoff{
+			current:    interval,
+			max:        opts.MaxInterval,
+			multiplier: opts.Multiplier,
+			jitter:     opts.Jitter,
+		}
+		if opts.Immediate {
+			if !WriteOne(ctx, ret, time.Now()) {
+				return
+			}
+		}
+		timer := time.NewTimer(b.next())
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-timer.C:
+				if !WriteOne(ctx, ret, now) {
+					return
+				}
+				timer.Reset(b.next())
+			}
+		}
+	}()
+
+	return ret
+}
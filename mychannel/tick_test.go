@@ -0,0 +1,69 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTick(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 20 * time.Millisecond
+	start := time.Now()
+	ticks := Tick(ctx, interval, TickOptions{})
+	for range 3 {
+		<-ticks
+	}
+	if elapsed := time.Since(start); elapsed < 3*interval {
+		t.Errorf("expected at least %s, took %s", 3*interval, elapsed)
+	}
+
+	cancel()
+	if _, ok := <-ticks; ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
+
+func TestTickImmediate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticks := Tick(ctx, time.Hour, TickOptions{Immediate: true})
+	select {
+	case <-ticks:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expected the first tick right away")
+	}
+}
+
+func TestTickBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 5 * time.Millisecond
+	ticks := Tick(ctx, interval, TickOptions{
+		Multiplier:  2,
+		MaxInterval: 20 * time.Millisecond,
+	})
+
+	// 5 + 10 + 20 + 20
+	start := time.Now()
+	for range 4 {
+		<-ticks
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("expected at least 55ms, took %s", elapsed)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := backoff{current: 100 * time.Millisecond, jitter: 0.5}
+	for range 100 {
+		d := b.next()
+		if d < 100*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("expected between 100ms and 150ms, got %s", d)
+		}
+	}
+}
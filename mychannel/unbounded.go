@@ -0,0 +1,119 @@
+package mychannel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Unbounded is a channel without a fixed capacity. Writes to In never block
+// for long because the values are moved to a growing buffer until someone
+// reads them from Out.
+type Unbounded[T any] struct {
+	in      chan T
+	out     chan T
+	softCap int
+
+	closeOnce sync.Once
+
+	length  atomic.Int64
+	peak    atomic.Int64
+	overCap atomic.Uint64
+}
+
+// NewUnbounded creates a new unbounded channel. The soft cap doesn't limit
+// anything, it's only used to count how many values were buffered while the
+// buffer was over it, see OverSoftCap. Use 0 to disable it.
+// Closing the input with Close sends the buffered values to Out and then
+// closes it. Cancelling the context closes Out immediately and the buffered
+// values are lost.
+func NewUnbounded[T any](ctx context.Context, softCap int) *Unbounded[T] {
+	u := &Unbounded[T]{
+		in:      make(chan T),
+		out:     make(chan T),
+		softCap: softCap,
+	}
+
+	go func() {
+		defer close(u.out)
+		var buf ring[T]
+		in := u.in
+		for in != nil || buf.len() > 0 {
+			// sending to a nil channel blocks, so nothing is sent while
+			// the buffer is empty
+			var out chan T
+			next, ok := buf.peek()
+			if ok {
+				out = u.out
+			}
+			select {
+			case v, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				buf.push(v)
+				u.track(buf.len())
+			case out <- next:
+				buf.pop()
+				u.length.Store(int64(buf.len()))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return u
+}
+
+func (u *Unbounded[T]) track(n int) {
+	u.length.Store(int64(n))
+	if int64(n) > u.peak.Load() {
+		u.peak.Store(int64(n))
+	}
+	if u.softCap > 0 && n > u.softCap {
+		u.overCap.Add(1)
+	}
+}
+
+// In returns the channel for writing. Don't close it directly, use Close.
+func (u *Unbounded[T]) In() chan<- T {
+	return u.in
+}
+
+// Out returns the channel for reading.
+func (u *Unbounded[T]) Out() <-chan T {
+	return u.out
+}
+
+// Close closes the input. The values which are already buffered can still be
+// read from Out.
+func (u *Unbounded[T]) Close() {
+	u.closeOnce.Do(func() {
+		close(u.in)
+	})
+}
+
+// Drain closes the input and discards all buffered values, like Drain does
+// for regular channels.
+func (u *Unbounded[T]) Drain() {
+	u.Close()
+	for range u.out {
+	}
+}
+
+// Len returns the number of buffered values.
+func (u *Unbounded[T]) Len() int {
+	return int(u.length.Load())
+}
+
+// Peak returns the highest number of values which were buffered at once.
+func (u *Unbounded[T]) Peak() int {
+	return int(u.peak.Load())
+}
+
+// OverSoftCap returns the number of values which were buffered while the
+// buffer was over the soft cap.
+func (u *Unbounded[T]) OverSoftCap() uint64 {
+	return u.overCap.Load()
+}
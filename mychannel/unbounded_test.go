@@ -0,0 +1,88 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUnbounded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	u := NewUnbounded[int](ctx, 100)
+	// nobody reads, but the writes don't block
+	for i := 0; i < 1000; i++ {
+		u.In() <- i
+	}
+	u.Close()
+	// closing twice does nothing
+	u.Close()
+
+	if u.Peak() < 999 {
+		t.Errorf("expected peak of at least 999, got %d", u.Peak())
+	}
+	if u.OverSoftCap() == 0 {
+		t.Errorf("expected values over the soft cap")
+	}
+
+	expected := 0
+	for v := range ReadWhile(ctx, u.Out()) {
+		if v != expected {
+			t.Fatalf("expected %d, got %d", expected, v)
+		}
+		expected++
+	}
+	if expected != 1000 {
+		t.Errorf("expected 1000 values, got %d", expected)
+	}
+	if u.Len() != 0 {
+		t.Errorf("expected empty buffer, got %d", u.Len())
+	}
+}
+
+func TestUnboundedDrain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	u := NewUnbounded[int](ctx, 0)
+	for i := 0; i < 10; i++ {
+		u.In() <- i
+	}
+	u.Drain()
+	if _, ok := <-u.Out(); ok {
+		t.Errorf("expected channel to be closed, but it is not")
+	}
+}
+
+func TestUnboundedContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	u := NewUnbounded[int](ctx, 0)
+	u.In() <- 1
+	cancel()
+	for range u.Out() {
+	}
+}
+
+func TestRing(t *testing.T) {
+	var r ring[int]
+	for i := 0; i < 100; i++ {
+		r.push(i)
+	}
+	for i := 0; i < 90; i++ {
+		if v, _ := r.pop(); v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	for i := 100; i < 120; i++ {
+		r.push(i)
+	}
+	for i := 90; i < 120; i++ {
+		if v, _ := r.pop(); v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	if _, ok := r.pop(); ok {
+		t.Errorf("expected empty ring")
+	}
+}
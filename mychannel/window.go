@@ -0,0 +1,130 @@
+package mychannel
+
+import (
+	"context"
+	"time"
+)
+
+type windowEntry[T any] struct {
+	v  T
+	at time.Time
+}
+
+// Window sends the values received during the last size every slide. When
+// slide is 0 or equal to size, the windows don't overlap (tumbling windows).
+// When slide is smaller than size, every value is sent in size/slide windows
+// (sliding windows). When slide is greater than size, the values received
+// between the windows are not sent at all (hopping windows).
+// Windows without values are sent as empty slices, so the output can be used
+// as a clock for rollups. When the input channel is closed, the current
+// window is sent if it's not empty and the output channel is closed.
+func Window[T any](ctx context.Context, in <-chan T, size, slide time.Duration) <-chan []T {
+	if size <= 0 {
+		panic("window size must be positive")
+	}
+	if slide <= 0 {
+		slide = size
+	}
+	tumbling := slide == size
+	ret := make(chan []T)
+
+	go func() {
+		defer close(ret)
+		ticker := time.NewTicker(slide)
+		defer ticker.Stop()
+
+		var entries []windowEntry[T]
+		// expire drops the values older than size
+		expire := func(now time.Time) {
+			drop := 0
+			for drop < len(entries) && now.Sub(entries[drop].at) > size {
+				drop++
+			}
+			entries = append(entries[:0], entries[drop:]...)
+		}
+		values := func() []T {
+			vals := make([]T, 0, len(entries))
+			for _, e := range entries {
+				vals = append(vals, e.v)
+			}
+			return vals
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if !tumbling {
+						expire(time.Now())
+					}
+					if len(entries) > 0 {
+						WriteOne(ctx, ret, values())
+					}
+					return
+				}
+				entries = append(entries, windowEntry[T]{v: v, at: time.Now()})
+			case now := <-ticker.C:
+				if tumbling {
+					vals := values()
+					entries = entries[:0]
+					if !WriteOne(ctx, ret, vals) {
+						return
+					}
+					continue
+				}
+				expire(now)
+				if !WriteOne(ctx, ret, values()) {
+					return
+				}
+			}
+		}
+	}()
+
+	return ret
+}
+
+// WindowReduce folds the values of every tumbling window of the given size
+// with fn, starting from the value returned by init, and sends the result.
+// Unlike Window, it doesn't keep the values, only the result, e.g. for counts
+// or sums. init is called for every window, so accumulators like maps or
+// slices are not shared between the windows. Windows without values send the
+// initial value. When the input channel is closed, the result of the current
+// window is sent if it has any values and the output channel is closed.
+func WindowReduce[T any, R any](ctx context.Context, in <-chan T, size time.Duration, init func() R, fn func(R, T) R) <-chan R {
+	if size <= 0 {
+		panic("window size must be positive")
+	}
+	ret := make(chan R)
+
+	go func() {
+		defer close(ret)
+		ticker := time.NewTicker(size)
+		defer ticker.Stop()
+
+		acc, cnt := init(), 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if cnt > 0 {
+						WriteOne(ctx, ret, acc)
+					}
+					return
+				}
+				acc = fn(acc, v)
+				cnt++
+			case <-ticker.C:
+				res := acc
+				acc, cnt = init(), 0
+				if !WriteOne(ctx, ret, res) {
+					return
+				}
+			}
+		}
+	}()
+
+	return ret
+}
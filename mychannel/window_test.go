@@ -0,0 +1,131 @@
+package mychannel
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWindowTumbling(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		in <- 1
+		in <- 2
+		time.Sleep(70 * time.Millisecond)
+		in <- 3
+	}()
+
+	var windows [][]int
+	for w := range Window(ctx, in, 50*time.Millisecond, 0) {
+		windows = append(windows, w)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("expected 2 windows, got %v", windows)
+	}
+	if len(windows[0]) != 2 || len(windows[1]) != 1 || windows[1][0] != 3 {
+		t.Errorf("expected [[1 2] [3]], got %v", windows)
+	}
+}
+
+func TestWindowSliding(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	out := Window(ctx, in, 100*time.Millisecond, 40*time.Millisecond)
+
+	in <- 1
+	// the value stays in the windows until it's older than the size
+	seen := 0
+	for range 4 {
+		w := <-out
+		if len(w) == 1 {
+			seen++
+		}
+	}
+	if seen < 2 || seen > 3 {
+		t.Errorf("expected the value in 2 or 3 windows, got %d", seen)
+	}
+	close(in)
+	for range out {
+	}
+}
+
+func TestWindowHopping(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	out := Window(ctx, in, 50*time.Millisecond, 150*time.Millisecond)
+
+	// the value is older than the size when the first window is sent
+	in <- 1
+	if w := <-out; len(w) != 0 {
+		t.Errorf("expected an empty window, got %v", w)
+	}
+
+	in <- 2
+	close(in)
+	w := <-out
+	if len(w) != 1 || w[0] != 2 {
+		t.Errorf("expected [2], got %v", w)
+	}
+	for range out {
+	}
+}
+
+func TestWindowReduce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan int)
+	zero := func() int { return 0 }
+	out := WindowReduce(ctx, in, 30*time.Millisecond, zero, func(sum, v int) int {
+		return sum + v
+	})
+
+	for i := 1; i <= 4; i++ {
+		in <- i
+	}
+	if sum := <-out; sum != 10 {
+		t.Errorf("expected 10, got %d", sum)
+	}
+	// the empty window sends the initial value
+	if sum := <-out; sum != 0 {
+		t.Errorf("expected 0, got %d", sum)
+	}
+
+	in <- 5
+	close(in)
+	var last int
+	for sum := range out {
+		last = sum
+	}
+	if last != 5 {
+		t.Errorf("expected 5, got %d", last)
+	}
+}
+
+func TestWindowReduceFreshAccumulator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	in := make(chan string)
+	newCounts := func() map[string]int { return map[string]int{} }
+	out := WindowReduce(ctx, in, 30*time.Millisecond, newCounts, func(counts map[string]int, k string) map[string]int {
+		counts[k]++
+		return counts
+	})
+
+	in <- "a"
+	first := <-out
+	in <- "a"
+	in <- "b"
+	close(in)
+	for range out {
+	}
+
+	// the later windows don't change the first one
+	if len(first) != 1 || first["a"] != 1 {
+		t.Errorf("expected map[a:1], got %v", first)
+	}
+}
@@ -2,7 +2,13 @@
 // using functions to define states.
 package myfsm
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+)
 
 type errstate struct {
 	error
@@ -42,13 +48,83 @@ type Transitioner interface {
 	Transition() Transitioner
 }
 
+// named is a state with an explicit name, see Named.
+type named struct {
+	name string
+	f    Func
+}
+
+func (n named) Transition() Transitioner {
+	return n.f()
+}
+
+// Named gives the state a name which is used in the hooks and the trace
+// instead of the function's name.
+func Named(name string, f Func) Transitioner {
+	return named{name: name, f: f}
+}
+
+// StateName returns the name of the state. Named states have their given name,
+// functions the name of the Go function (closures get names like "pkg.fn.func1")
+// and other Transitioners their type.
+func StateName(t Transitioner) string {
+	switch v := t.(type) {
+	case named:
+		return v.name
+	case Func:
+		if fn := runtime.FuncForPC(reflect.ValueOf(v).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", t)
+}
+
+// Step is a single visited state in the Trace.
+type Step struct {
+	State    string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Trace is the list of the visited states in order.
+type Trace []Step
+
+// Options configures StartWithOptions.
+type Options struct {
+	// BeforeTransition is called with the name of the state before it runs.
+	BeforeTransition func(state string)
+	// AfterTransition is called after the state ran, also if it panicked.
+	AfterTransition func(step Step)
+}
+
 func Start(ctx context.Context, initial Transitioner) (ret any, err error) {
+	ret, _, err = run(ctx, initial, Options{}, false)
+	return ret, err
+}
+
+// StartWithOptions is like Start, but calls the hooks around every state and
+// returns the trace of the visited states.
+func StartWithOptions(ctx context.Context, initial Transitioner, opts Options) (ret any, trace Trace, err error) {
+	return run(ctx, initial, opts, true)
+}
+
+func run(ctx context.Context, initial Transitioner, opts Options, record bool) (ret any, trace Trace, err error) {
+	// the names are only needed for the hooks and the trace
+	naming := record || opts.BeforeTransition != nil || opts.AfterTransition != nil
 	var reterror error
 	var retValue any
 	for f := initial; f != nil && reterror == nil; {
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			return nil, trace, ctx.Err()
+		}
+		var step Step
+		if naming {
+			step.State = StateName(f)
+		}
+		if opts.BeforeTransition != nil {
+			opts.BeforeTransition(step.State)
 		}
+		step.Start = time.Now()
 		var next Transitioner
 		func() {
 			defer func() {
@@ -58,6 +134,13 @@ func Start(ctx context.Context, initial Transitioner) (ret any, err error) {
 			}()
 			next = f.Transition()
 		}()
+		step.Duration = time.Since(step.Start)
+		if record {
+			trace = append(trace, step)
+		}
+		if opts.AfterTransition != nil {
+			opts.AfterTransition(step)
+		}
 
 		errstate, ok := next.(errstate)
 		if ok {
@@ -77,5 +160,5 @@ func Start(ctx context.Context, initial Transitioner) (ret any, err error) {
 
 		f = next
 	}
-	return retValue, reterror
+	return retValue, trace, reterror
 }
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTransitioningSimpleState(t *testing.T) {
@@ -74,3 +77,91 @@ func TestTransitioningSimpleState(t *testing.T) {
 		}
 	})
 }
+
+func TestStartWithOptions(t *testing.T) {
+	ctx := context.Background()
+
+	var before []string
+	var after []string
+	opts := Options{
+		BeforeTransition: func(state string) {
+			before = append(before, state)
+		},
+		AfterTransition: func(step Step) {
+			after = append(after, step.State)
+		},
+	}
+
+	provision := Named("provision", func() Transitioner {
+		time.Sleep(10 * time.Millisecond)
+		return Return("done")
+	})
+	prepare := Named("prepare", func() Transitioner {
+		return provision
+	})
+	ret, trace, err := StartWithOptions(ctx, prepare, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret != "done" {
+		t.Fatalf("unexpected return value: %v", ret)
+	}
+
+	expected := []string{"prepare", "provision"}
+	if !slices.Equal(before, expected) {
+		t.Fatalf("unexpected before hooks: %v", before)
+	}
+	if !slices.Equal(after, expected) {
+		t.Fatalf("unexpected after hooks: %v", after)
+	}
+	if len(trace) != 2 {
+		t.Fatalf("unexpected trace length: %d", len(trace))
+	}
+	for i, step := range trace {
+		if step.State != expected[i] {
+			t.Fatalf("unexpected state %d: %s", i, step.State)
+		}
+	}
+	if trace[1].Duration < 10*time.Millisecond {
+		t.Fatalf("unexpected duration: %s", trace[1].Duration)
+	}
+	if trace[1].Start.Before(trace[0].Start) {
+		t.Fatalf("unexpected start times: %v", trace)
+	}
+}
+
+func TestStartWithOptionsError(t *testing.T) {
+	ctx := context.Background()
+	failing := Named("failing", func() Transitioner {
+		panic(fmt.Errorf("test"))
+	})
+	_, trace, err := StartWithOptions(ctx, failing, Options{})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(trace) != 1 || trace[0].State != "failing" {
+		t.Fatalf("unexpected trace: %v", trace)
+	}
+}
+
+type structState struct{}
+
+func (structState) Transition() Transitioner {
+	return nil
+}
+
+func unnamedState() Transitioner {
+	return structState{}
+}
+
+func TestStateName(t *testing.T) {
+	if name := StateName(Named("baba", unnamedState)); name != "baba" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	if name := StateName(Func(unnamedState)); !strings.HasSuffix(name, "myfsm.unnamedState") {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	if name := StateName(structState{}); name != "myfsm.structState" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+}
@@ -0,0 +1,138 @@
+package mytest
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakGracePeriod is how long the goroutines have to exit after the test.
+const leakGracePeriod = time.Second
+
+// ignoredFrames are the functions of the goroutines which belong to the Go
+// runtime or the testing package.
+var ignoredFrames = []string{
+	"testing.(*M).",
+	"testing.(*T).Run(",
+	"testing.(*T).Parallel(",
+	"testing.tRunner(",
+	"testing.runTests(",
+	"os/signal.signal_recv(",
+	"os/signal.loop(",
+	"runtime.ensureSigM(",
+	"runtime/trace.Start.",
+}
+
+type goroutine struct {
+	id    string
+	stack string
+}
+
+// goroutines returns all running goroutines except the calling one and the
+// ones from ignoredFrames.
+func goroutines() []goroutine {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var ret []goroutine
+	// the first one is always the calling goroutine
+	for _, stack := range strings.Split(string(buf), "\n\n")[1:] {
+		header, _, _ := strings.Cut(stack, "\n")
+		id := strings.Fields(header)[1]
+		if isIgnored(stack) {
+			continue
+		}
+		ret = append(ret, goroutine{id: id, stack: stack})
+	}
+	return ret
+}
+
+func isIgnored(stack string) bool {
+	for _, line := range strings.Split(stack, "\n") {
+		// the goroutines started by the tests are "created by" the test
+		// functions, only the frames of the goroutine itself matter
+		if strings.HasPrefix(line, "created by ") {
+			break
+		}
+		for _, frame := range ignoredFrames {
+			if strings.HasPrefix(line, frame) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// leaks waits for the goroutines which are not in the snapshot to exit and
+// returns the ones still running after the grace period.
+func leaks(snapshot map[string]struct{}, grace time.Duration) []goroutine {
+	deadline := time.Now().Add(grace)
+	for {
+		var leaked []goroutine
+		for _, g := range goroutines() {
+			if _, ok := snapshot[g.id]; !ok {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func formatLeaks(leaked []goroutine) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "found %d leaked goroutine(s):\n", len(leaked))
+	for _, g := range leaked {
+		sb.WriteString("\n")
+		sb.WriteString(g.stack)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// VerifyNoLeaks fails the test if it leaves goroutines running. It snapshots
+// the running goroutines when called and checks them again in the test's
+// cleanup, giving them a grace period to exit.
+// Other tests running at the same time look like leaks, so don't use it in
+// tests calling t.Parallel, use VerifyTestMain instead.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+	snapshot := make(map[string]struct{})
+	for _, g := range goroutines() {
+		snapshot[g.id] = struct{}{}
+	}
+	t.Cleanup(func() {
+		if leaked := leaks(snapshot, leakGracePeriod); len(leaked) > 0 {
+			t.Error(formatLeaks(leaked))
+		}
+	})
+}
+
+// VerifyTestMain runs all tests of the package and fails if any goroutines
+// are left running afterwards. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		mytest.VerifyTestMain(m)
+//	}
+func VerifyTestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 {
+		if leaked := leaks(nil, leakGracePeriod); len(leaked) > 0 {
+			fmt.Fprintln(os.Stderr, formatLeaks(leaked))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
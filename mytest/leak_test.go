@@ -0,0 +1,41 @@
+package mytest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaks(t *testing.T) {
+	snapshot := make(map[string]struct{})
+	for _, g := range goroutines() {
+		snapshot[g.id] = struct{}{}
+	}
+
+	release := make(chan struct{})
+	go func() {
+		<-release
+	}()
+
+	leaked := leaks(snapshot, 20*time.Millisecond)
+	if len(leaked) != 1 {
+		t.Fatalf("expected 1 leaked goroutine, got %d", len(leaked))
+	}
+
+	close(release)
+	if leaked := leaks(snapshot, time.Second); len(leaked) != 0 {
+		t.Errorf("expected no leaked goroutines, got:\n%s", formatLeaks(leaked))
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	VerifyNoLeaks(t)
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+	// the goroutine exits within the grace period, so it's not a leak
+	t.Cleanup(func() {
+		<-done
+	})
+}